@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/archive"
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/google/uuid"
+)
+
+const (
+	// accountDeletionGrace is how long a deleted account can still be
+	// restored before it and everything it owns is removed.
+	accountDeletionGrace = 30 * 24 * time.Hour
+
+	// dataExportTTL is how long a finished export is served before a new
+	// request builds a fresh one.
+	dataExportTTL = 24 * time.Hour
+
+	jobKindDeleteUser = "users.delete"
+	jobKindExportUser = "users.export"
+)
+
+type Session struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	Scopes    []string   `json:"scopes"`
+}
+
+type deleteUserJob struct {
+	UserID      uuid.UUID `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type exportUserJob struct {
+	ExportID uuid.UUID `json:"export_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+// deleteAccountHandler schedules the caller's account for deletion after
+// re-checking their password, and TOTP code if enabled. Every session and API
+// key is revoked straight away; logging in again and restoring the account
+// within the grace period cancels the deletion.
+func (config *apiConfig) deleteAccountHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	type response struct {
+		DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	}
+
+	caller := principalFromContext(request.Context())
+	if caller.APIKeyID != uuid.Nil {
+		respondWithError(writer, http.StatusForbidden, "API keys can't delete accounts")
+		return
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+	if dbUser.DeletionRequestedAt.Valid {
+		respondWithError(writer, http.StatusConflict, "Account deletion already requested")
+		return
+	}
+	if !dbUser.HashedPassword.Valid {
+		respondWithError(writer, http.StatusForbidden, "Set a password before deleting the account")
+		return
+	}
+	err = auth.CheckPasswordHash(params.Password, dbUser.HashedPassword.String)
+	if err != nil {
+		respondWithError(writer, http.StatusUnauthorized, "Incorrect password")
+		return
+	}
+	if dbUser.TotpEnabled {
+		err = auth.ValidateTOTP(params.Code, dbUser.TotpSecret.String, time.Now())
+		if err != nil {
+			respondWithError(writer, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+	}
+
+	var scheduledAt time.Time
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		pending, err := queries.RequestUserDeletion(request.Context(), dbUser.ID)
+		if err != nil {
+			return err
+		}
+		err = queries.RevokeUserRefreshTokens(request.Context(), dbUser.ID)
+		if err != nil {
+			return err
+		}
+		err = queries.RevokeUserAPIKeys(request.Context(), dbUser.ID)
+		if err != nil {
+			return err
+		}
+
+		requestedAt := pending.DeletionRequestedAt.Time
+		scheduledAt = requestedAt.Add(accountDeletionGrace)
+		_, err = jobs.Enqueue(request.Context(), queries, jobKindDeleteUser, deleteUserJob{
+			UserID:      dbUser.ID,
+			RequestedAt: requestedAt,
+		}, scheduledAt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(request.Context(), queries, auditDeletionRequested, dbUser.ID, dbUser.ID, nil)
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete account")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusAccepted, response{DeletionScheduledAt: scheduledAt})
+}
+
+func (config *apiConfig) restoreAccountHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbUser, err := config.databaseQueries.CancelUserDeletion(request.Context(), userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusConflict, "No account deletion pending")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't restore account")
+		return
+	}
+	config.audit(request.Context(), auditDeletionCancelled, userId, userId, nil)
+
+	respondWithJSON(writer, http.StatusOK, config.userFromDB(request.Context(), dbUser))
+}
+
+// deleteUserJob removes an account whose grace period has passed. The job is
+// skipped if the deletion was cancelled, or cancelled and requested again, in
+// which case a later job handles it.
+func (config *apiConfig) deleteUserJob(ctx context.Context, payload json.RawMessage) error {
+	job := deleteUserJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(ctx, job.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !dbUser.DeletionRequestedAt.Valid || !dbUser.DeletionRequestedAt.Time.Equal(job.RequestedAt) {
+		return nil
+	}
+
+	return config.withTx(ctx, func(queries *database.Queries) error {
+		chirpIDs, err := queries.UserChirpIDs(ctx, dbUser.ID)
+		if err != nil {
+			return err
+		}
+		if len(chirpIDs) > 0 {
+			err = enqueueMediaDeletion(ctx, queries, chirpIDs)
+			if err != nil {
+				return err
+			}
+		}
+		// Everything else the user owns goes with the row via ON DELETE CASCADE.
+		return queries.DelUser(ctx, dbUser.ID)
+	})
+}
+
+// exportHandler serves the caller's data export as a ZIP of JSON files. The
+// first request, or one after the last export went stale, starts building a
+// new export and returns 202; clients poll until it's ready.
+func (config *apiConfig) exportHandler(writer http.ResponseWriter, request *http.Request) {
+	type response struct {
+		Status      string    `json:"status"`
+		RequestedAt time.Time `json:"requested_at"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	latest, err := config.databaseQueries.LatestDataExport(request.Context(), userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load export")
+		return
+	}
+	if err == nil && time.Since(latest.CreatedAt) < dataExportTTL {
+		if !latest.CompletedAt.Valid {
+			writer.Header().Set("Retry-After", "30")
+			respondWithJSON(writer, http.StatusAccepted, response{Status: "pending", RequestedAt: latest.CreatedAt})
+			return
+		}
+		filename := fmt.Sprintf("chirpy-export-%s.zip", latest.CompletedAt.Time.Format("2006-01-02"))
+		writer.Header().Set("Content-Type", "application/zip")
+		writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		writer.WriteHeader(http.StatusOK)
+		writer.Write(latest.Archive)
+		return
+	}
+
+	var created database.DataExport
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		err := queries.DelDataExports(request.Context(), userId)
+		if err != nil {
+			return err
+		}
+		created, err = queries.CreateDataExport(request.Context(), userId)
+		if err != nil {
+			return err
+		}
+		_, err = jobs.Enqueue(request.Context(), queries, jobKindExportUser, exportUserJob{
+			ExportID: created.ID,
+			UserID:   userId,
+		}, time.Now())
+		return err
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't start export")
+		return
+	}
+
+	writer.Header().Set("Retry-After", "30")
+	respondWithJSON(writer, http.StatusAccepted, response{Status: "pending", RequestedAt: created.CreatedAt})
+}
+
+// exportUserJob gathers the user's profile, chirps, sessions and subscription
+// history into the export's archive.
+func (config *apiConfig) exportUserJob(ctx context.Context, payload json.RawMessage) error {
+	job := exportUserJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(ctx, job.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dbChirps, err := config.databaseQueries.AllChirpsAuthorID(ctx, dbUser.ID)
+	if err != nil {
+		return err
+	}
+	chirps, err := config.renderChirps(ctx, dbChirps)
+	if err != nil {
+		return err
+	}
+
+	dbSessions, err := config.databaseQueries.UserRefreshTokens(ctx, dbUser.ID)
+	if err != nil {
+		return err
+	}
+	sessions := []Session{}
+	for _, dbSession := range dbSessions {
+		sessions = append(sessions, Session{
+			CreatedAt: dbSession.CreatedAt,
+			ExpiresAt: dbSession.ExpiresAt,
+			RevokedAt: timePointer(dbSession.RevokedAt),
+			Scopes:    dbSession.Scopes,
+		})
+	}
+
+	dbHistory, err := config.databaseQueries.UserSubscriptionHistory(ctx, dbUser.ID)
+	if err != nil {
+		return err
+	}
+	history := []SubscriptionHistory{}
+	for _, entry := range dbHistory {
+		history = append(history, SubscriptionHistory{
+			CreatedAt: entry.CreatedAt,
+			Event:     entry.Event,
+			Status:    entry.Status,
+			ExpiresAt: timePointer(entry.ExpiresAt),
+		})
+	}
+
+	buffer := &bytes.Buffer{}
+	err = archive.Write(buffer, []archive.File{
+		{Name: "profile.json", Data: config.userFromDB(ctx, dbUser)},
+		{Name: "chirps.json", Data: chirps},
+		{Name: "sessions.json", Data: sessions},
+		{Name: "subscription_history.json", Data: history},
+	}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return config.databaseQueries.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:      job.ExportID,
+		Archive: buffer.Bytes(),
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// requireAdmin only lets through callers whose user row is flagged is_admin.
+// API keys are never accepted for admin routes.
+func (config *apiConfig) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		caller, err := config.authenticateRequest(request)
+		if err != nil {
+			respondWithError(writer, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if caller.APIKeyID != uuid.Nil {
+			respondWithError(writer, http.StatusForbidden, "Admin access requires a login token")
+			return
+		}
+		dbUser, err := config.databaseQueries.UserByID(request.Context(), caller.UserID)
+		if err != nil || !dbUser.IsAdmin {
+			respondWithError(writer, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next(writer, request.WithContext(context.WithValue(request.Context(), principalKey{}, caller)))
+	}
+}
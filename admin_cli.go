@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/fixtures"
+	"github.com/joho/godotenv"
+)
+
+const adminUsage = `usage: chirpy admin <command> [arguments]
+
+Commands:
+  truncate <scope>...        empty the tables of each scope (%s)
+  seed [-reset] <file.yaml>  load a fixture; -reset truncates users first
+
+Both refuse to run unless the DB_URL database is commented '%s'.
+`
+
+// runAdmin implements the "chirpy admin" subcommands, returning the process
+// exit code.
+func runAdmin(args []string, stdout, stderr io.Writer) int {
+	usage := fmt.Sprintf(adminUsage, strings.Join(fixtures.Scopes(), ", "), fixtures.DisposableMarker)
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	godotenv.Load()
+	db, err := sql.Open("postgres", os.Getenv("DB_URL"))
+	if err != nil {
+		fmt.Fprintf(stderr, "connecting to database: %s\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	config := &apiConfig{db: db, databaseQueries: database.New(db)}
+
+	switch args[0] {
+	case "truncate":
+		err = fixtures.Truncate(ctx, db, args[1:])
+		if err == nil {
+			fmt.Fprintf(stdout, "truncated %s\n", strings.Join(args[1:], ", "))
+		}
+	case "seed":
+		err = config.adminSeed(ctx, args[1:], stdout)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "chirpy admin %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func (config *apiConfig) adminSeed(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	reset := flags.Bool("reset", false, "truncate users before seeding")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one fixture file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fixture, err := fixtures.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	if *reset {
+		err = fixtures.Truncate(ctx, config.db, []string{"users"})
+	} else {
+		err = fixtures.CheckDisposable(ctx, config.db)
+	}
+	if err != nil {
+		return err
+	}
+
+	var counts fixtures.Counts
+	err = config.withTx(ctx, func(queries *database.Queries) error {
+		counts, err = fixtures.Seed(ctx, queries, fixture)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "seeded %d users, %d chirps and %d follows\n", counts.Users, counts.Chirps, counts.Follows)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func apiKeyFromDB(dbKey database.ApiKey) APIKey {
+	apiKey := APIKey{
+		ID:        dbKey.ID,
+		CreatedAt: dbKey.CreatedAt,
+		Name:      dbKey.Name,
+		Prefix:    dbKey.KeyPrefix,
+		Scopes:    dbKey.Scopes,
+	}
+	if dbKey.LastUsedAt.Valid {
+		apiKey.LastUsedAt = &dbKey.LastUsedAt.Time
+	}
+	if dbKey.RevokedAt.Valid {
+		apiKey.RevokedAt = &dbKey.RevokedAt.Time
+	}
+	return apiKey
+}
+
+// apiKeyOwner only accepts Bearer tokens so a leaked API key can't be used to
+// mint further keys.
+func apiKeyOwner(request *http.Request) (uuid.UUID, error) {
+	caller := principalFromContext(request.Context())
+	if caller.APIKeyID != uuid.Nil {
+		return uuid.Nil, errors.New("API keys can't manage API keys")
+	}
+	return caller.UserID, nil
+}
+
+func (config *apiConfig) createAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	type response struct {
+		APIKey
+		Key string `json:"key"`
+	}
+
+	userId, err := apiKeyOwner(request)
+	if err != nil {
+		respondWithError(writer, http.StatusForbidden, err.Error())
+		return
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if params.Name == "" || len(params.Scopes) == 0 {
+		respondWithError(writer, http.StatusBadRequest, "Name and scopes are required")
+		return
+	}
+	if !auth.ValidScopes(params.Scopes) {
+		respondWithError(writer, http.StatusBadRequest, "Unknown scope")
+		return
+	}
+	// A key can't hold more than the session that minted it.
+	if missing := auth.MissingScope(principalFromContext(request.Context()).Scopes, params.Scopes...); missing != "" {
+		respondWithError(writer, http.StatusForbidden, "Can't grant scope "+missing)
+		return
+	}
+
+	key, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create API key")
+		return
+	}
+
+	dbKey, err := config.databaseQueries.CreateAPIKey(request.Context(), database.CreateAPIKeyParams{
+		Name:      params.Name,
+		KeyPrefix: key[:15],
+		KeyHash:   auth.HashAPIKey(key),
+		Scopes:    params.Scopes,
+		UserID:    userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create API key")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, response{
+		APIKey: apiKeyFromDB(dbKey),
+		Key:    key,
+	})
+}
+
+func (config *apiConfig) listAPIKeysHandler(writer http.ResponseWriter, request *http.Request) {
+	userId, err := apiKeyOwner(request)
+	if err != nil {
+		respondWithError(writer, http.StatusForbidden, err.Error())
+		return
+	}
+
+	dbKeys, err := config.databaseQueries.ListAPIKeys(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list API keys")
+		return
+	}
+
+	apiKeys := []APIKey{}
+	for _, dbKey := range dbKeys {
+		apiKeys = append(apiKeys, apiKeyFromDB(dbKey))
+	}
+	respondWithJSON(writer, http.StatusOK, apiKeys)
+}
+
+func (config *apiConfig) revokeAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	userId, err := apiKeyOwner(request)
+	if err != nil {
+		respondWithError(writer, http.StatusForbidden, err.Error())
+		return
+	}
+
+	id, err := uuid.Parse(request.PathValue("keyID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	revoked, err := config.databaseQueries.RevokeAPIKey(request.Context(), database.RevokeAPIKeyParams{
+		ID:     id,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't revoke API key")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(writer, http.StatusNotFound, "No API key found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
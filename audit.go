@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+const (
+	auditLoginSucceeded       = "login.succeeded"
+	auditLoginFailed          = "login.failed"
+	auditTokenRefreshed       = "token.refreshed"
+	auditTokenRevoked         = "token.revoked"
+	auditCredentialsChanged   = "user.credentials_changed"
+	auditDeletionRequested    = "user.deletion_requested"
+	auditDeletionCancelled    = "user.deletion_cancelled"
+	auditSubscriptionUpgraded = "subscription.upgraded"
+	auditChirpDeleted         = "chirp.deleted"
+	auditChirpRestored        = "chirp.restored"
+	auditAdminReset           = "admin.reset"
+	auditAdminSeed            = "admin.seed"
+)
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Action    string          `json:"action"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	TargetID  *uuid.UUID      `json:"target_id"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Details   json.RawMessage `json:"details"`
+}
+
+func auditEntryFromDB(dbEntry database.AuditLog) AuditEntry {
+	entry := AuditEntry{
+		ID:        dbEntry.ID,
+		CreatedAt: dbEntry.CreatedAt,
+		Action:    dbEntry.Action,
+		IP:        dbEntry.Ip,
+		UserAgent: dbEntry.UserAgent,
+		RequestID: dbEntry.RequestID,
+		Details:   dbEntry.Details,
+	}
+	if dbEntry.ActorID.Valid {
+		entry.ActorID = &dbEntry.ActorID.UUID
+	}
+	if dbEntry.TargetID.Valid {
+		entry.TargetID = &dbEntry.TargetID.UUID
+	}
+	return entry
+}
+
+// requestInfo identifies where a request came from, for the audit log.
+type requestInfo struct {
+	ID        string
+	IP        string
+	UserAgent string
+}
+
+type requestInfoKey struct{}
+
+func requestInfoFromContext(ctx context.Context) requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info
+}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// withRequestInfo tags every request with an ID, reusing a well-formed
+// X-Request-ID from the client or proxy, and echoes it in the response.
+func (config *apiConfig) withRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		id := request.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		writer.Header().Set(requestIDHeader, id)
+
+		info := requestInfo{
+			ID:        id,
+			IP:        config.clientIP(request),
+			UserAgent: request.UserAgent(),
+		}
+		next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), requestInfoKey{}, info)))
+	})
+}
+
+// clientIP is the peer address, or when TRUSTED_PROXIES says how many
+// proxies sit in front of us, the X-Forwarded-For hop the outermost of them
+// added. Hops further left are whatever the client sent and can't be trusted.
+func (config *apiConfig) clientIP(request *http.Request) string {
+	if config.trustedProxies > 0 {
+		hops := strings.Split(request.Header.Get("X-Forwarded-For"), ",")
+		if i := len(hops) - config.trustedProxies; i >= 0 {
+			if ip := net.ParseIP(strings.TrimSpace(hops[i])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
+
+// recordAudit appends to the audit log, taking the client details from ctx.
+// Like emitEvent, pass the queries of the transaction making the change so
+// the entry commits with it.
+func recordAudit(ctx context.Context, queries *database.Queries, action string, actor, target uuid.UUID, details interface{}) error {
+	if details == nil {
+		details = struct{}{}
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	info := requestInfoFromContext(ctx)
+	return queries.CreateAuditEntry(ctx, database.CreateAuditEntryParams{
+		Action:    action,
+		ActorID:   uuid.NullUUID{UUID: actor, Valid: actor != uuid.Nil},
+		TargetID:  uuid.NullUUID{UUID: target, Valid: target != uuid.Nil},
+		Ip:        info.IP,
+		UserAgent: info.UserAgent,
+		RequestID: info.ID,
+		Details:   data,
+	})
+}
+
+// audit records an action taken outside a transaction. A failure is logged
+// rather than returned so that auditing never blocks the action itself.
+func (config *apiConfig) audit(ctx context.Context, action string, actor, target uuid.UUID, details interface{}) {
+	err := recordAudit(ctx, config.databaseQueries, action, actor, target, details)
+	if err != nil {
+		fmt.Printf("Couldn't audit %s: %s\n", action, err)
+	}
+}
+
+// listAuditHandler pages through the audit log, newest first, filtered by
+// any of action, actor_id, target_id, ip, and an RFC 3339 since/until range.
+func (config *apiConfig) listAuditHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := request.URL.Query()
+	params := database.ListAuditEntriesParams{
+		Action: sql.NullString{String: query.Get("action"), Valid: query.Get("action") != ""},
+		Ip:     sql.NullString{String: query.Get("ip"), Valid: query.Get("ip") != ""},
+		Limit:  limit,
+		Offset: offset,
+	}
+	for name, field := range map[string]*uuid.NullUUID{"actor_id": &params.ActorID, "target_id": &params.TargetID} {
+		if value := query.Get(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				respondWithError(writer, http.StatusBadRequest, "Invalid "+name)
+				return
+			}
+			*field = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+	for name, field := range map[string]*sql.NullTime{"since": &params.Since, "until": &params.Until} {
+		if value := query.Get(name); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondWithError(writer, http.StatusBadRequest, "Invalid "+name)
+				return
+			}
+			*field = sql.NullTime{Time: at.UTC(), Valid: true}
+		}
+	}
+
+	dbEntries, err := config.databaseQueries.ListAuditEntries(request.Context(), params)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list audit log")
+		return
+	}
+
+	entries := []AuditEntry{}
+	for _, dbEntry := range dbEntries {
+		entries = append(entries, auditEntryFromDB(dbEntry))
+	}
+	respondWithJSON(writer, http.StatusOK, entries)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies int
+		forwardedFor   string
+		want           string
+	}{
+		{
+			name:         "No proxy ignores the header",
+			forwardedFor: "203.0.113.7",
+			want:         "192.0.2.1",
+		},
+		{
+			name:           "One proxy takes the rightmost hop",
+			trustedProxies: 1,
+			forwardedFor:   "198.51.100.9, 203.0.113.7",
+			want:           "203.0.113.7",
+		},
+		{
+			name:           "Two proxies skip the inner proxy's hop",
+			trustedProxies: 2,
+			forwardedFor:   "198.51.100.9, 203.0.113.7, 10.0.0.2",
+			want:           "203.0.113.7",
+		},
+		{
+			name:           "Fewer hops than proxies falls back to the peer",
+			trustedProxies: 2,
+			forwardedFor:   "203.0.113.7",
+			want:           "192.0.2.1",
+		},
+		{
+			name:           "Missing header falls back to the peer",
+			trustedProxies: 1,
+			want:           "192.0.2.1",
+		},
+		{
+			name:           "Malformed hop falls back to the peer",
+			trustedProxies: 1,
+			forwardedFor:   "203.0.113.7, not-an-ip",
+			want:           "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := apiConfig{trustedProxies: tt.trustedProxies}
+			request := httptest.NewRequest("GET", "/", nil)
+			request.RemoteAddr = "192.0.2.1:4242"
+			if tt.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := config.clientIP(request); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "abc-123_DEF.4", want: true},
+		{id: "", want: false},
+		{id: "has space", want: false},
+		{id: "line\nbreak", want: false},
+		{id: "semi;colon", want: false},
+		{id: string(make([]byte, 65)), want: false},
+		{id: "a234567890123456789012345678901234567890123456789012345678901234", want: true},
+		{id: "a2345678901234567890123456789012345678901234567890123456789012345", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := validRequestID.MatchString(tt.id); got != tt.want {
+			t.Errorf("validRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maxCollectionNameLength = 64
+
+type Bookmark struct {
+	ChirpID      uuid.UUID  `json:"chirp_id"`
+	BookmarkedAt time.Time  `json:"bookmarked_at"`
+	CollectionID *uuid.UUID `json:"collection_id"`
+	Chirp        *Chirp     `json:"chirp,omitempty"`
+}
+
+func bookmarkFromDB(dbBookmark database.Bookmark) Bookmark {
+	bookmark := Bookmark{
+		ChirpID:      dbBookmark.ChirpID,
+		BookmarkedAt: dbBookmark.CreatedAt,
+	}
+	if dbBookmark.CollectionID.Valid {
+		bookmark.CollectionID = &dbBookmark.CollectionID.UUID
+	}
+	return bookmark
+}
+
+type Collection struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func collectionFromDB(dbCollection database.Collection) Collection {
+	return Collection{
+		ID:        dbCollection.ID,
+		CreatedAt: dbCollection.CreatedAt,
+		UpdatedAt: dbCollection.UpdatedAt,
+		Name:      dbCollection.Name,
+	}
+}
+
+// addBookmarkHandler bookmarks a chirp for the caller, optionally filed in
+// one of their collections. Bookmarking it again moves it between
+// collections and keeps the original bookmark time.
+func (config *apiConfig) addBookmarkHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		CollectionID *uuid.UUID `json:"collection_id"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	chirpId, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	_, err = config.databaseQueries.SingleChirp(request.Context(), chirpId)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	collection := uuid.NullUUID{}
+	if params.CollectionID != nil {
+		dbCollection, err := config.databaseQueries.Collection(request.Context(), database.CollectionParams{
+			ID:     *params.CollectionID,
+			UserID: userId,
+		})
+		if err != nil {
+			respondWithError(writer, http.StatusNotFound, "Collection not found")
+			return
+		}
+		collection = uuid.NullUUID{UUID: dbCollection.ID, Valid: true}
+	}
+
+	dbBookmark, err := config.databaseQueries.AddBookmark(request.Context(), database.AddBookmarkParams{
+		UserID:       userId,
+		ChirpID:      chirpId,
+		CollectionID: collection,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't save bookmark")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, bookmarkFromDB(dbBookmark))
+}
+
+func (config *apiConfig) deleteBookmarkHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	chirpId, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	count, err := config.databaseQueries.DelBookmark(request.Context(), database.DelBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete bookmark")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Bookmark not found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// listBookmarksHandler pages through the caller's bookmarks, most recently
+// bookmarked first, optionally limited to one collection.
+func (config *apiConfig) listBookmarksHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UserBookmarksParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if value := request.URL.Query().Get("collection_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(writer, http.StatusBadRequest, "Invalid collection_id")
+			return
+		}
+		params.CollectionID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	dbBookmarks, err := config.databaseQueries.UserBookmarks(request.Context(), params)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list bookmarks")
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(dbBookmarks))
+	for _, dbBookmark := range dbBookmarks {
+		ids = append(ids, dbBookmark.ChirpID)
+	}
+	chirps := map[uuid.UUID]Chirp{}
+	if len(ids) > 0 {
+		dbChirps, err := config.databaseQueries.ChirpsByIDs(request.Context(), ids)
+		if err != nil {
+			respondWithError(writer, http.StatusInternalServerError, "Couldn't list bookmarks")
+			return
+		}
+		rendered, err := config.renderChirps(request.Context(), dbChirps)
+		if err != nil {
+			respondWithError(writer, http.StatusInternalServerError, "Couldn't list bookmarks")
+			return
+		}
+		for _, chirp := range rendered {
+			chirps[chirp.ID] = chirp
+		}
+	}
+
+	bookmarks := []Bookmark{}
+	for _, dbBookmark := range dbBookmarks {
+		chirp, ok := chirps[dbBookmark.ChirpID]
+		if !ok {
+			// Deleted since the bookmarks were read.
+			continue
+		}
+		bookmark := bookmarkFromDB(dbBookmark)
+		bookmark.Chirp = &chirp
+		bookmarks = append(bookmarks, bookmark)
+	}
+	respondWithJSON(writer, http.StatusOK, bookmarks)
+}
+
+// readCollectionName decodes a collection body, writing the error response
+// itself when it returns false.
+func readCollectionName(writer http.ResponseWriter, request *http.Request) (string, bool) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return "", false
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(name) > maxCollectionNameLength {
+		respondWithError(writer, http.StatusBadRequest, "Collection name must be 1 to 64 characters")
+		return "", false
+	}
+	return name, true
+}
+
+func (config *apiConfig) createCollectionHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	name, ok := readCollectionName(writer, request)
+	if !ok {
+		return
+	}
+
+	dbCollection, err := config.databaseQueries.CreateCollection(request.Context(), database.CreateCollectionParams{
+		UserID: userId,
+		Name:   name,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(writer, http.StatusConflict, "Collection already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create collection")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, collectionFromDB(dbCollection))
+}
+
+func (config *apiConfig) listCollectionsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbCollections, err := config.databaseQueries.UserCollections(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list collections")
+		return
+	}
+
+	collections := []Collection{}
+	for _, dbCollection := range dbCollections {
+		collections = append(collections, collectionFromDB(dbCollection))
+	}
+	respondWithJSON(writer, http.StatusOK, collections)
+}
+
+func (config *apiConfig) renameCollectionHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	collectionId, err := uuid.Parse(request.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid collection ID")
+		return
+	}
+	name, ok := readCollectionName(writer, request)
+	if !ok {
+		return
+	}
+
+	dbCollection, err := config.databaseQueries.RenameCollection(request.Context(), database.RenameCollectionParams{
+		ID:     collectionId,
+		UserID: userId,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusNotFound, "Collection not found")
+		return
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(writer, http.StatusConflict, "Collection already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't rename collection")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, collectionFromDB(dbCollection))
+}
+
+// deleteCollectionHandler removes a collection. Its bookmarks are kept,
+// just no longer filed anywhere.
+func (config *apiConfig) deleteCollectionHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	collectionId, err := uuid.Parse(request.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid collection ID")
+		return
+	}
+
+	count, err := config.databaseQueries.DelCollection(request.Context(), database.DelCollectionParams{
+		ID:     collectionId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete collection")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Collection not found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
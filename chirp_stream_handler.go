@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/stream"
+	"github.com/google/uuid"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// publishChirpEvent emits a chirp event to webhook subscribers and records it
+// for the chirp stream. Like emitEvent it must be given the queries bound to
+// the transaction making the change.
+func (config *apiConfig) publishChirpEvent(ctx context.Context, queries *database.Queries, event string, chirpID, userID uuid.UUID, data interface{}) error {
+	err := config.emitEvent(ctx, queries, event, userID, data)
+	if err != nil {
+		return err
+	}
+	return recordStreamEvent(ctx, queries, event, chirpID, userID, uuid.NullUUID{}, data)
+}
+
+// recordStreamEvent appends to chirp_stream_events, addressed to recipientID
+// if set, and notifies listening replicas. The NOTIFY is only delivered once
+// the surrounding transaction commits.
+func recordStreamEvent(ctx context.Context, queries *database.Queries, event string, chirpID, userID uuid.UUID, recipientID uuid.NullUUID, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	id, err := queries.CreateChirpStreamEvent(ctx, database.CreateChirpStreamEventParams{
+		Event:       event,
+		ChirpID:     chirpID,
+		UserID:      userID,
+		Payload:     payload,
+		RecipientID: recipientID,
+	})
+	if err != nil {
+		return err
+	}
+	return queries.NotifyChirpStream(ctx, id)
+}
+
+// chirpStreamHandler pushes chirp events as server-sent events. Clients that
+// reconnect with Last-Event-ID first receive anything they missed, as long as
+// it is still within the stream's retention window.
+func (config *apiConfig) chirpStreamHandler(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		respondWithError(writer, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	topic := stream.Topic{Name: stream.TopicTimeline}
+	if author := request.URL.Query().Get("author_id"); author != "" {
+		var err error
+		topic.AuthorID, err = uuid.Parse(author)
+		if err != nil {
+			respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+			return
+		}
+	}
+
+	var lastID int64
+	if lastEventID := request.Header.Get("Last-Event-ID"); lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			respondWithError(writer, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	// Subscribe before replaying so nothing committed in between is lost;
+	// anything seen twice is skipped by comparing against lastID.
+	subscriber := config.chirpStream.Subscribe()
+	defer config.chirpStream.Unsubscribe(subscriber)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(http.StatusOK)
+
+	send := func(event database.ChirpStreamEvent) error {
+		if event.ID <= lastID {
+			return nil
+		}
+		lastID = event.ID
+		if !topic.Matches(event) {
+			return nil
+		}
+		_, err := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Event, event.Payload)
+		return err
+	}
+
+	if request.Header.Get("Last-Event-ID") != "" {
+		// Replay only as far as the hub has settled; anything later arrives
+		// through the subscription, in order.
+		untilID := config.chirpStream.LastID()
+		for {
+			events, err := config.databaseQueries.ChirpStreamEventsAfter(request.Context(), database.ChirpStreamEventsAfterParams{
+				AfterID: lastID,
+				UntilID: untilID,
+				Limit:   stream.ReplayLimit,
+			})
+			if err != nil {
+				return
+			}
+			for _, event := range events {
+				if send(event) != nil {
+					return
+				}
+			}
+			if len(events) < stream.ReplayLimit {
+				break
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case event, ok := <-subscriber.Events:
+			if !ok {
+				// Fell too far behind; the client resumes via Last-Event-ID.
+				return
+			}
+			if send(event) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(writer, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -1,77 +1,332 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"time"
 
-	"github.com/amstein4920/chirpy-http-server/internal/auth"
 	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/entities"
+	"github.com/amstein4920/chirpy-http-server/internal/entitlements"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/amstein4920/chirpy-http-server/internal/media"
+	"github.com/amstein4920/chirpy-http-server/internal/webhooks"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// chirpUndoWindow is how long after deleting a chirp its author can
+	// restore it.
+	chirpUndoWindow = 15 * time.Minute
+	// chirpRetention is how long a deleted chirp is kept, for moderation,
+	// before it is purged.
+	chirpRetention = 30 * 24 * time.Hour
+
+	jobKindPurgeChirp = "chirps.purge"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ReplyToID  *uuid.UUID `json:"reply_to_id,omitempty"`
+	RepostOfID *uuid.UUID `json:"repost_of_id,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+
+	Entities []entities.Entity `json:"entities"`
+	Media    []Media           `json:"media"`
+	Preview  *LinkPreview      `json:"preview,omitempty"`
+
+	// RepostOf is the reposted Chirp, or a ChirpTombstone once it's gone.
+	RepostOf     interface{} `json:"repost_of,omitempty"`
+	RechirpCount int64       `json:"rechirp_count"`
+	QuoteCount   int64       `json:"quote_count"`
+}
+
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:        dbChirp.ID,
+		CreatedAt: dbChirp.CreatedAt,
+		UpdatedAt: dbChirp.UpdatedAt,
+		Body:      dbChirp.Body,
+		UserID:    dbChirp.UserID,
+		Media:     []Media{},
+	}
+	if dbChirp.ReplyToID.Valid {
+		chirp.ReplyToID = &dbChirp.ReplyToID.UUID
+	}
+	if dbChirp.RepostOfID.Valid {
+		chirp.RepostOfID = &dbChirp.RepostOfID.UUID
+	}
+	chirp.DeletedAt = timePointer(dbChirp.DeletedAt)
+	if json.Unmarshal(dbChirp.Entities, &chirp.Entities) != nil || chirp.Entities == nil {
+		chirp.Entities = []entities.Entity{}
+	}
+	return chirp
+}
+
+// renderChirps converts chirps for a response, attaching their media, link
+// previews and repost counts, and embedding the chirps they repost.
+func (config *apiConfig) renderChirps(ctx context.Context, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirps, err := config.renderChirpsOnly(ctx, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+	return chirps, config.embedReposts(ctx, chirps)
+}
+
+// renderChirpsOnly is renderChirps without embedding reposted chirps, which
+// keeps an embedded quote of a quote from nesting any further.
+func (config *apiConfig) renderChirpsOnly(ctx context.Context, dbChirps []database.Chirp) ([]Chirp, error) {
+	ids := make([]uuid.UUID, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		ids = append(ids, dbChirp.ID)
+	}
+	rows := []database.ChirpMedium{}
+	if len(ids) > 0 {
+		var err error
+		rows, err = config.databaseQueries.MediaForChirps(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	previews, err := config.linkPreviewsFor(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := config.repostCountsFor(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	attached := map[uuid.UUID][]Media{}
+	for _, row := range rows {
+		attached[row.ChirpID] = append(attached[row.ChirpID], config.mediaFromDB(row))
+	}
+
+	chirps := make([]Chirp, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		chirp := chirpFromDB(dbChirp)
+		if found, ok := attached[dbChirp.ID]; ok {
+			chirp.Media = found
+		}
+		chirp.Preview = previews[dbChirp.ID]
+		chirp.RechirpCount = counts[dbChirp.ID].Rechirps
+		chirp.QuoteCount = counts[dbChirp.ID].Quotes
+		chirps = append(chirps, chirp)
+	}
+	return chirps, nil
 }
 
 func (config *apiConfig) chirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	params, err := readChirpUpload(writer, request)
+	var tooLarge *http.MaxBytesError
+	if errors.Is(err, media.ErrTooLarge) || errors.As(err, &tooLarge) {
+		respondWithError(writer, http.StatusRequestEntityTooLarge, "Attachment is too large")
+		return
+	}
+	if errors.Is(err, media.ErrUnsupported) || errors.Is(err, errTooManyMedia) || errors.Is(err, errInvalidReplyTo) || errors.Is(err, errInvalidPublishAt) {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		fmt.Printf("Invalid JSON: %s", err)
+		writer.WriteHeader(500)
+		return
+	}
+
+	if params.PublishAt != nil {
+		config.scheduleChirp(writer, request, params)
+		return
+	}
+
+	params.Body, err = validateChirpBody(params.Body, config.entitlementsFor(request.Context(), userId))
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+
+	replyTo, err := config.replyTarget(request.Context(), params.ReplyToID)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Reply target not found")
+		return
+	}
+
+	parsed, err := config.parseChirpEntities(request.Context(), params.Body)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't parse chirp")
+		return
+	}
+
+	stored, err := config.storeMedia(request.Context(), params.Images)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't store attachments")
+		return
+	}
+
+	var returnChirp Chirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		returnChirp, err = config.insertChirp(request.Context(), queries, database.CreateChirpParams{
+			Body:      params.Body,
+			UserID:    userId,
+			ReplyToID: replyTo,
+			Entities:  parsed.JSON(),
+		}, parsed, stored)
+		return err
+	})
+	if err != nil {
+		config.deleteStoredMedia(request.Context(), stored)
+		respondWithError(writer, 500, fmt.Sprintf("Chirp not created: %s", err.Error()))
+		return
+	}
+
+	respondWithJSON(writer, 201, returnChirp)
+}
+
+// insertChirp creates a chirp with its entities, links and already stored
+// media, and announces it to subscribers and mentioned users.
+func (config *apiConfig) insertChirp(ctx context.Context, queries *database.Queries, params database.CreateChirpParams, parsed chirpEntities, stored []database.CreateChirpMediaParams) (Chirp, error) {
+	dbChirp, err := queries.CreateChirp(ctx, params)
+	if err != nil {
+		return Chirp{}, err
+	}
+	err = saveChirpEntities(ctx, queries, dbChirp.ID, parsed)
+	if err != nil {
+		return Chirp{}, err
+	}
+	err = saveChirpLinks(ctx, queries, dbChirp.ID, dbChirp.Body)
+	if err != nil {
+		return Chirp{}, err
+	}
+
+	chirp := chirpFromDB(dbChirp)
+	for _, row := range stored {
+		row.ChirpID = dbChirp.ID
+		dbMedia, err := queries.CreateChirpMedia(ctx, row)
+		if err != nil {
+			return Chirp{}, err
+		}
+		chirp.Media = append(chirp.Media, config.mediaFromDB(dbMedia))
+	}
+
+	err = config.publishChirpEvent(ctx, queries, webhooks.EventChirpCreated, chirp.ID, chirp.UserID, chirp)
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirp, enqueueChirpNotifications(ctx, queries, chirp.ID)
+}
+
+// replyTarget checks that the chirp being replied to, if any, exists.
+func (config *apiConfig) replyTarget(ctx context.Context, id *uuid.UUID) (uuid.NullUUID, error) {
+	if id == nil {
+		return uuid.NullUUID{}, nil
+	}
+	parent, err := config.databaseQueries.SingleChirp(ctx, *id)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: parent.ID, Valid: true}, nil
+}
+
+// validateChirpBody enforces the author's length limit and censors the body.
+func validateChirpBody(body string, allowed entitlements.Set) (string, error) {
+	if len(body) > allowed.MaxChirpLength {
+		return "", errors.New("Chirp is too long")
+	}
+	return censorMessage(body), nil
+}
+
+func (config *apiConfig) editChirpHandler(writer http.ResponseWriter, request *http.Request) {
 	type parameters struct {
 		Body string `json:"body"`
 	}
 
-	token, err := auth.GetBearerToken(request.Header)
-	if err != nil {
-		respondWithError(writer, 401, err.Error())
+	userId := principalFromContext(request.Context()).UserID
+	allowed := config.entitlementsFor(request.Context(), userId)
+	if !allowed.EditChirps {
+		respondWithError(writer, http.StatusForbidden, "Editing chirps requires Chirpy Red")
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, config.secret)
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	chirp, err := config.databaseQueries.SingleChirp(request.Context(), id)
 	if err != nil {
-		respondWithError(writer, 401, "Unauthorized")
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+	if chirp.UserID != userId {
+		respondWithError(writer, http.StatusForbidden, "Unauthorized")
+		return
+	}
+	if isRechirp(chirp) {
+		respondWithError(writer, http.StatusBadRequest, "Rechirps can't be edited")
 		return
 	}
 
-	decoder := json.NewDecoder(request.Body)
 	params := parameters{}
-
+	decoder := json.NewDecoder(request.Body)
 	err = decoder.Decode(&params)
 	if err != nil {
-		fmt.Printf("Invalid JSON: %s", err)
-		writer.WriteHeader(500)
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	if len(params.Body) > 140 {
-		respondWithError(writer, 400, "Chirp is too long")
+	body, err := validateChirpBody(params.Body, allowed)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	params.Body = censorMessage(params.Body)
+	parsed, err := config.parseChirpEntities(request.Context(), body)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't parse chirp")
+		return
+	}
 
-	dbChirp, err := config.databaseQueries.CreateChirp(request.Context(), database.CreateChirpParams{
-		Body:   params.Body,
-		UserID: userId,
+	var dbChirp database.Chirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		var err error
+		dbChirp, err = queries.UpdateChirp(request.Context(), database.UpdateChirpParams{
+			ID:       chirp.ID,
+			Body:     body,
+			Entities: parsed.JSON(),
+		})
+		if err != nil {
+			return err
+		}
+		err = saveChirpEntities(request.Context(), queries, chirp.ID, parsed)
+		if err != nil {
+			return err
+		}
+		return saveChirpLinks(request.Context(), queries, chirp.ID, body)
 	})
 	if err != nil {
-		respondWithError(writer, 500, fmt.Sprintf("Chirp not created: %s", err.Error()))
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not updated")
 		return
 	}
 
-	returnChirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	returnChirps, err := config.renderChirps(request.Context(), []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not retrieved")
+		return
 	}
-
-	respondWithJSON(writer, 201, returnChirp)
+	respondWithJSON(writer, http.StatusOK, returnChirps[0])
 }
 
 func (config *apiConfig) allChirpsHandler(writer http.ResponseWriter, request *http.Request) {
@@ -104,16 +359,10 @@ func (config *apiConfig) allChirpsHandler(writer http.ResponseWriter, request *h
 		}
 	}
 
-	returnChirps := []Chirp{}
-
-	for _, dbChirp := range dbChirps {
-		returnChirps = append(returnChirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID,
-		})
+	returnChirps, err := config.renderChirps(request.Context(), dbChirps)
+	if err != nil {
+		respondWithError(writer, 500, fmt.Sprintf("Chirps not retrieved: %s", err))
+		return
 	}
 	sort.Slice(returnChirps, func(i, j int) bool {
 		if sortDirection == "asc" {
@@ -138,50 +387,181 @@ func (config *apiConfig) singleChirpsHandler(writer http.ResponseWriter, request
 		return
 	}
 
-	returnChirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	returnChirps, err := config.renderChirps(request.Context(), []database.Chirp{dbChirp})
+	if err != nil {
+		writer.WriteHeader(500)
+		return
 	}
 
-	respondWithJSON(writer, 200, returnChirp)
+	respondWithJSON(writer, 200, returnChirps[0])
 }
 
 func (config *apiConfig) deleteChirpHandler(writer http.ResponseWriter, request *http.Request) {
-	accessToken, err := auth.GetBearerToken(request.Header)
+	userId := principalFromContext(request.Context()).UserID
+
+	id, err := uuid.Parse(request.PathValue("chirpID"))
 	if err != nil {
-		respondWithError(writer, http.StatusUnauthorized, "No Access")
+		respondWithError(writer, http.StatusInternalServerError, "Invalid ID")
 		return
 	}
-	userId, err := auth.ValidateJWT(accessToken, config.secret)
+	chirp, err := config.databaseQueries.SingleChirp(request.Context(), id)
 	if err != nil {
-		respondWithError(writer, http.StatusUnauthorized, "No Access")
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
 		return
 	}
 
+	if chirp.UserID != userId {
+		respondWithError(writer, http.StatusForbidden, "Unauthorized")
+		return
+	}
+
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		deleted, err := queries.SoftDelChirp(request.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+		_, err = jobs.Enqueue(request.Context(), queries, jobKindPurgeChirp, purgeChirpJob{
+			ChirpID:   chirp.ID,
+			DeletedAt: deleted.DeletedAt.Time,
+		}, deleted.DeletedAt.Time.Add(chirpRetention))
+		if err != nil {
+			return err
+		}
+		err = recordAudit(request.Context(), queries, auditChirpDeleted, userId, chirp.ID, nil)
+		if err != nil {
+			return err
+		}
+		data := map[string]uuid.UUID{
+			"id":      chirp.ID,
+			"user_id": chirp.UserID,
+		}
+		if chirp.ReplyToID.Valid {
+			data["reply_to_id"] = chirp.ReplyToID.UUID
+		}
+		return config.publishChirpEvent(request.Context(), queries, webhooks.EventChirpDeleted, chirp.ID, userId, data)
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// restoreChirpHandler undoes a delete made within the last chirpUndoWindow.
+func (config *apiConfig) restoreChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
 	id, err := uuid.Parse(request.PathValue("chirpID"))
 	if err != nil {
-		respondWithError(writer, http.StatusInternalServerError, "Invalid ID")
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
 		return
 	}
-	chirp, err := config.databaseQueries.SingleChirp(request.Context(), id)
+	chirp, err := config.databaseQueries.ChirpIncludingDeleted(request.Context(), id)
 	if err != nil {
 		respondWithError(writer, http.StatusNotFound, "No Chirp found")
 		return
 	}
-
 	if chirp.UserID != userId {
 		respondWithError(writer, http.StatusForbidden, "Unauthorized")
 		return
 	}
+	if !chirp.DeletedAt.Valid {
+		respondWithError(writer, http.StatusConflict, "Chirp isn't deleted")
+		return
+	}
+
+	var restored database.Chirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		restored, err = queries.RestoreChirp(request.Context(), database.RestoreChirpParams{
+			ID:        chirp.ID,
+			DeletedAt: sql.NullTime{Time: time.Now().UTC().Add(-chirpUndoWindow), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		err = recordAudit(request.Context(), queries, auditChirpRestored, userId, chirp.ID, nil)
+		if err != nil {
+			return err
+		}
+		return config.publishChirpEvent(request.Context(), queries, webhooks.EventChirpRestored, restored.ID, userId, chirpFromDB(restored))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusGone, "Too late to undo this delete")
+		return
+	}
+	// A deleted rechirp can't come back once the chirp has been rechirped
+	// again.
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(writer, http.StatusConflict, "Already rechirped")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not restored")
+		return
+	}
 
-	err = config.databaseQueries.DelChirp(request.Context(), chirp.ID)
+	returnChirps, err := config.renderChirps(request.Context(), []database.Chirp{restored})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not retrieved")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, returnChirps[0])
+}
+
+// adminChirpHandler shows any chirp, including deleted ones awaiting purge.
+func (config *apiConfig) adminChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	dbChirp, err := config.databaseQueries.ChirpIncludingDeleted(request.Context(), id)
 	if err != nil {
 		respondWithError(writer, http.StatusNotFound, "No Chirp found")
 		return
 	}
 
-	writer.WriteHeader(http.StatusNoContent)
+	returnChirps, err := config.renderChirps(request.Context(), []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not retrieved")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, returnChirps[0])
+}
+
+type purgeChirpJob struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// purgeChirpJob permanently removes a chirp, and its media, once the
+// retention period has passed. It does nothing if the chirp was restored,
+// or restored and deleted again, in which case a later job handles it.
+func (config *apiConfig) purgeChirpJob(ctx context.Context, payload json.RawMessage) error {
+	job := purgeChirpJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	chirp, err := config.databaseQueries.ChirpIncludingDeleted(ctx, job.ChirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !chirp.DeletedAt.Valid || !chirp.DeletedAt.Time.Equal(job.DeletedAt) {
+		return nil
+	}
+
+	return config.withTx(ctx, func(queries *database.Queries) error {
+		err := enqueueMediaDeletion(ctx, queries, []uuid.UUID{chirp.ID})
+		if err != nil {
+			return err
+		}
+		return queries.DelChirp(ctx, chirp.ID)
+	})
 }
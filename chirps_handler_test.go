@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var chirpColumns = []string{"id", "created_at", "updated_at", "body", "user_id", "reply_to_id", "entities", "deleted_at", "repost_of_id"}
+
+func TestRestoreRechirpAlreadyRechirped(t *testing.T) {
+	userID, chirpID := uuid.New(), uuid.New()
+
+	db := dbtest.New()
+	db.Handle("ChirpIncludingDeleted", func(args []driver.Value) (dbtest.Rows, error) {
+		now := time.Now().UTC()
+		return dbtest.Rows{
+			Columns: chirpColumns,
+			Values:  [][]driver.Value{{chirpID.String(), now, now, "", userID.String(), nil, []byte(`[]`), now, uuid.NewString()}},
+		}, nil
+	})
+	db.Handle("RestoreChirp", func(args []driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{}, &pq.Error{Code: "23505"}
+	})
+	config := apiConfig{db: db.DB, databaseQueries: database.New(db)}
+
+	request := httptest.NewRequest("POST", "/api/chirps/"+chirpID.String()+"/restore", nil)
+	request.SetPathValue("chirpID", chirpID.String())
+	request = request.WithContext(context.WithValue(request.Context(), principalKey{}, principal{UserID: userID}))
+	recorder := httptest.NewRecorder()
+	config.restoreChirpHandler(recorder, request)
+
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("restoreChirpHandler() status = %d, want %d", recorder.Code, http.StatusConflict)
+	}
+}
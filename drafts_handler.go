@@ -0,0 +1,251 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxDraftLength bounds what can be saved as a draft. The author's chirp
+// length limit is only enforced when the draft is published.
+const maxDraftLength = 10000
+
+type Draft struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+}
+
+func draftFromDB(dbDraft database.Draft) Draft {
+	draft := Draft{
+		ID:        dbDraft.ID,
+		CreatedAt: dbDraft.CreatedAt,
+		UpdatedAt: dbDraft.UpdatedAt,
+		Body:      dbDraft.Body,
+	}
+	if dbDraft.ReplyToID.Valid {
+		draft.ReplyToID = &dbDraft.ReplyToID.UUID
+	}
+	return draft
+}
+
+type draftParameters struct {
+	Body      string     `json:"body"`
+	ReplyToID *uuid.UUID `json:"reply_to_id"`
+}
+
+// readDraft decodes and checks a draft body, writing the error response
+// itself when it returns false.
+func (config *apiConfig) readDraft(writer http.ResponseWriter, request *http.Request) (string, uuid.NullUUID, bool) {
+	params := draftParameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return "", uuid.NullUUID{}, false
+	}
+	if len(params.Body) > maxDraftLength {
+		respondWithError(writer, http.StatusBadRequest, "Draft is too long")
+		return "", uuid.NullUUID{}, false
+	}
+
+	replyTo, err := config.replyTarget(request.Context(), params.ReplyToID)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Reply target not found")
+		return "", uuid.NullUUID{}, false
+	}
+	return params.Body, replyTo, true
+}
+
+func (config *apiConfig) createDraftHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	body, replyTo, ok := config.readDraft(writer, request)
+	if !ok {
+		return
+	}
+
+	dbDraft, err := config.databaseQueries.CreateDraft(request.Context(), database.CreateDraftParams{
+		UserID:    userId,
+		Body:      body,
+		ReplyToID: replyTo,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't save draft")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, draftFromDB(dbDraft))
+}
+
+func (config *apiConfig) updateDraftHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	draftId, err := uuid.Parse(request.PathValue("draftID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid draft ID")
+		return
+	}
+	body, replyTo, ok := config.readDraft(writer, request)
+	if !ok {
+		return
+	}
+
+	dbDraft, err := config.databaseQueries.UpdateDraft(request.Context(), database.UpdateDraftParams{
+		ID:        draftId,
+		UserID:    userId,
+		Body:      body,
+		ReplyToID: replyTo,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusNotFound, "Draft not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't save draft")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, draftFromDB(dbDraft))
+}
+
+// listDraftsHandler pages through the caller's drafts, most recently edited
+// first.
+func (config *apiConfig) listDraftsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbDrafts, err := config.databaseQueries.UserDrafts(request.Context(), database.UserDraftsParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list drafts")
+		return
+	}
+
+	drafts := []Draft{}
+	for _, dbDraft := range dbDrafts {
+		drafts = append(drafts, draftFromDB(dbDraft))
+	}
+	respondWithJSON(writer, http.StatusOK, drafts)
+}
+
+func (config *apiConfig) deleteDraftHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	draftId, err := uuid.Parse(request.PathValue("draftID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid draft ID")
+		return
+	}
+
+	count, err := config.databaseQueries.DelDraft(request.Context(), database.DelDraftParams{
+		ID:     draftId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete draft")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Draft not found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// publishDraftHandler turns a draft into a chirp, applying the same length
+// limit and censoring as posting one directly. The chirp it replies to may
+// have been deleted since the draft was saved, in which case the draft is
+// kept. The draft is removed in the same transaction, so publishing it twice
+// fails the second time.
+func (config *apiConfig) publishDraftHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	draftId, err := uuid.Parse(request.PathValue("draftID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid draft ID")
+		return
+	}
+
+	dbDraft, err := config.databaseQueries.Draft(request.Context(), database.DraftParams{
+		ID:     draftId,
+		UserID: userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusNotFound, "Draft not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load draft")
+		return
+	}
+
+	body, err := validateChirpBody(dbDraft.Body, config.entitlementsFor(request.Context(), userId))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var replyToID *uuid.UUID
+	if dbDraft.ReplyToID.Valid {
+		replyToID = &dbDraft.ReplyToID.UUID
+	}
+	replyTo, err := config.replyTarget(request.Context(), replyToID)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Reply target not found")
+		return
+	}
+
+	parsed, err := config.parseChirpEntities(request.Context(), body)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't parse chirp")
+		return
+	}
+
+	var returnChirp Chirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		count, err := queries.DelDraft(request.Context(), database.DelDraftParams{
+			ID:     dbDraft.ID,
+			UserID: userId,
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return sql.ErrNoRows
+		}
+		returnChirp, err = config.insertChirp(request.Context(), queries, database.CreateChirpParams{
+			Body:      body,
+			UserID:    userId,
+			ReplyToID: replyTo,
+			Entities:  parsed.JSON(),
+		}, parsed, nil)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusNotFound, "Draft not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't publish draft")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, returnChirp)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/amstein4920/chirpy-http-server/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+func TestPublishDraftWithDeletedReplyTarget(t *testing.T) {
+	userID, draftID, parentID := uuid.New(), uuid.New(), uuid.New()
+
+	db := dbtest.New()
+	db.Handle("Draft", func(args []driver.Value) (dbtest.Rows, error) {
+		now := time.Now().UTC()
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "user_id", "body", "reply_to_id"},
+			Values:  [][]driver.Value{{draftID.String(), now, now, userID.String(), "hi", parentID.String()}},
+		}, nil
+	})
+	db.Handle("UserIsChirpyRed", func(args []driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"red"}, Values: [][]driver.Value{{false}}}, nil
+	})
+	db.Handle("SingleChirp", func(args []driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: []string{"id"}}, nil
+	})
+	db.Handle("DelDraft", func(args []driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Affected: 1}, nil
+	})
+	config := apiConfig{db: db.DB, databaseQueries: database.New(db), entitlements: entitlements.Default()}
+
+	request := httptest.NewRequest("POST", "/api/drafts/"+draftID.String()+"/publish", nil)
+	request.SetPathValue("draftID", draftID.String())
+	request = request.WithContext(context.WithValue(request.Context(), principalKey{}, principal{UserID: userID}))
+	recorder := httptest.NewRecorder()
+	config.publishDraftHandler(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("publishDraftHandler() status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	if calls := db.Calls("DelDraft"); len(calls) != 0 {
+		t.Errorf("DelDraft committed %d times, want 0", len(calls))
+	}
+}
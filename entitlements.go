@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/amstein4920/chirpy-http-server/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+func (config *apiConfig) entitlementsFor(ctx context.Context, userID uuid.UUID) entitlements.Set {
+	return config.entitlements.For(config.isChirpyRed(ctx, userID))
+}
+
+// rateLimit caps each user at their tier's requests per minute. It must run
+// inside requireScopes so the caller is known.
+func (config *apiConfig) rateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId := principalFromContext(request.Context()).UserID
+		limit := config.entitlementsFor(request.Context(), userId).RequestsPerMinute
+
+		allowed, retryAfter := config.rateLimiter.Allow(userId.String(), limit)
+		if !allowed {
+			writer.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+			respondWithError(writer, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next(writer, request)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/google/uuid"
+)
+
+const jobKindPublishEvent = "event.publish"
+
+type eventJob struct {
+	Event  string          `json:"event"`
+	UserID uuid.UUID       `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// emitEvent records a domain event in the jobs outbox. Pass the queries bound
+// to the transaction making the change so the event is published only if the
+// change commits.
+func (config *apiConfig) emitEvent(ctx context.Context, queries *database.Queries, event string, userID uuid.UUID, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = jobs.Enqueue(ctx, queries, jobKindPublishEvent, eventJob{
+		Event:  event,
+		UserID: userID,
+		Data:   payload,
+	}, time.Now())
+	return err
+}
+
+// publishEventJob fans an event out to outgoing webhook subscribers.
+func (config *apiConfig) publishEventJob(ctx context.Context, payload json.RawMessage) error {
+	job := eventJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	_, err = config.databaseQueries.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		Event:   job.Event,
+		Payload: job.Data,
+		UserID:  job.UserID,
+	})
+	return err
+}
+
+func (config *apiConfig) registerJobs(runner *jobs.Runner) {
+	runner.Register(jobKindPublishEvent, config.publishEventJob)
+	runner.Register(jobKindChirpNotifications, config.chirpNotificationsJob)
+	runner.Register(jobKindDeleteMedia, config.deleteMediaJob)
+	runner.Register(jobKindLinkPreviews, config.linkPreviewsJob)
+	runner.Register(jobKindDeleteUser, config.deleteUserJob)
+	runner.Register(jobKindExportUser, config.exportUserJob)
+	runner.Register(jobKindPurgeChirp, config.purgeChirpJob)
+	runner.Register(jobKindPublishChirp, config.publishChirpJob)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// FollowEntry is one user in a followers or following list.
+type FollowEntry struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+// followHandler makes the caller follow a user, notifying them the first
+// time. Following someone already followed is a no-op.
+func (config *apiConfig) followHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbUser, err := config.userByIDOrHandle(request.Context(), request.PathValue("idOrHandle"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+	if dbUser.ID == userId {
+		respondWithError(writer, http.StatusBadRequest, "Can't follow yourself")
+		return
+	}
+
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		count, err := queries.FollowUser(request.Context(), database.FollowUserParams{
+			FollowerID: userId,
+			FolloweeID: dbUser.ID,
+		})
+		if err != nil || count == 0 {
+			return err
+		}
+		return notify(request.Context(), queries, dbUser.ID, userId, notificationFollow, uuid.Nil)
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't follow user")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unfollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbUser, err := config.userByIDOrHandle(request.Context(), request.PathValue("idOrHandle"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+
+	count, err := config.databaseQueries.UnfollowUser(request.Context(), database.UnfollowUserParams{
+		FollowerID: userId,
+		FolloweeID: dbUser.ID,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't unfollow user")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Not following user")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// followersHandler pages through the users following someone, most recent
+// first.
+func (config *apiConfig) followersHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	dbUser, err := config.userByIDOrHandle(request.Context(), request.PathValue("idOrHandle"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+
+	rows, err := config.databaseQueries.UserFollowers(request.Context(), database.UserFollowersParams{
+		FolloweeID: dbUser.ID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list followers")
+		return
+	}
+
+	entries := []FollowEntry{}
+	for _, row := range rows {
+		entries = append(entries, FollowEntry{
+			ID:          row.ID,
+			Handle:      row.Handle.String,
+			DisplayName: row.DisplayName,
+			FollowedAt:  row.FollowedAt,
+		})
+	}
+	respondWithJSON(writer, http.StatusOK, entries)
+}
+
+// followingHandler pages through the users someone follows, most recent
+// first.
+func (config *apiConfig) followingHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	dbUser, err := config.userByIDOrHandle(request.Context(), request.PathValue("idOrHandle"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+
+	rows, err := config.databaseQueries.UserFollowing(request.Context(), database.UserFollowingParams{
+		FollowerID: dbUser.ID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list following")
+		return
+	}
+
+	entries := []FollowEntry{}
+	for _, row := range rows {
+		entries = append(entries, FollowEntry{
+			ID:          row.ID,
+			Handle:      row.Handle.String,
+			DisplayName: row.DisplayName,
+			FollowedAt:  row.FollowedAt,
+		})
+	}
+	respondWithJSON(writer, http.StatusOK, entries)
+}
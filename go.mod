@@ -10,3 +10,11 @@ require (
 )
 
 require github.com/golang-jwt/jwt/v5 v5.2.1
+
+require github.com/gorilla/websocket v1.5.3
+
+require golang.org/x/image v0.18.0
+
+require golang.org/x/net v0.31.0
+
+require gopkg.in/yaml.v3 v3.0.1
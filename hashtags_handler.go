@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	trendingLimit         = 10
+)
+
+// chirpEntities are the mentions and hashtags parsed from a chirp body, with
+// mentions resolved to users where possible.
+type chirpEntities struct {
+	Found     []entities.Entity
+	Tags      []string
+	Mentioned []uuid.UUID
+}
+
+func (parsed chirpEntities) JSON() json.RawMessage {
+	data, _ := json.Marshal(parsed.Found)
+	return data
+}
+
+func (config *apiConfig) parseChirpEntities(ctx context.Context, body string) (chirpEntities, error) {
+	found := entities.Parse(body)
+	users, err := config.resolveMentions(ctx, entities.Texts(found, entities.TypeMention))
+	if err != nil {
+		return chirpEntities{}, err
+	}
+	return chirpEntities{
+		Found:     found,
+		Tags:      entities.Texts(found, entities.TypeHashtag),
+		Mentioned: entities.Resolve(found, users),
+	}, nil
+}
+
+// resolveMentions maps mention texts to the users they name, by email
+// address or handle.
+func (config *apiConfig) resolveMentions(ctx context.Context, texts []string) (map[string]uuid.UUID, error) {
+	users := map[string]uuid.UUID{}
+
+	emails := []string{}
+	names := []string{}
+	for _, text := range texts {
+		if strings.Contains(text, "@") {
+			emails = append(emails, text)
+		} else {
+			names = append(names, text)
+		}
+	}
+
+	if len(emails) > 0 {
+		rows, err := config.databaseQueries.UsersByEmails(ctx, emails)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			users[row.Email] = row.ID
+		}
+	}
+	if len(names) > 0 {
+		rows, err := config.databaseQueries.UsersByHandles(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			users[row.Handle] = row.ID
+		}
+	}
+	return users, nil
+}
+
+// saveChirpEntities replaces the chirp's rows in the hashtag and mention
+// tables.
+func saveChirpEntities(ctx context.Context, queries *database.Queries, chirpID uuid.UUID, parsed chirpEntities) error {
+	err := queries.DelChirpHashtags(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = queries.DelChirpMentions(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	if len(parsed.Tags) > 0 {
+		err = queries.AddChirpHashtags(ctx, database.AddChirpHashtagsParams{
+			ChirpID: chirpID,
+			Tags:    parsed.Tags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(parsed.Mentioned) > 0 {
+		err = queries.AddChirpMentions(ctx, database.AddChirpMentionsParams{
+			ChirpID: chirpID,
+			UserIds: parsed.Mentioned,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (config *apiConfig) hashtagChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(request.PathValue("tag"), "#"))
+	dbChirps, err := config.databaseQueries.HashtagChirps(request.Context(), database.HashtagChirpsParams{
+		Tag:    tag,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirps not retrieved")
+		return
+	}
+
+	returnChirps, err := config.renderChirps(request.Context(), dbChirps)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirps not retrieved")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, returnChirps)
+}
+
+// trendingHashtagsHandler ranks hashtags by use over the trailing window,
+// given in hours and defaulting to a day.
+func (config *apiConfig) trendingHashtagsHandler(writer http.ResponseWriter, request *http.Request) {
+	type trend struct {
+		Tag  string `json:"tag"`
+		Uses int64  `json:"uses"`
+	}
+
+	window := defaultTrendingWindow
+	if value := request.URL.Query().Get("hours"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 1 {
+			respondWithError(writer, http.StatusBadRequest, "invalid hours")
+			return
+		}
+		window = min(time.Duration(hours)*time.Hour, maxTrendingWindow)
+	}
+
+	rows, err := config.databaseQueries.TrendingHashtags(request.Context(), database.TrendingHashtagsParams{
+		CreatedAt: time.Now().UTC().Add(-window),
+		Limit:     trendingLimit,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't compute trends")
+		return
+	}
+
+	trends := []trend{}
+	for _, row := range rows {
+		trends = append(trends, trend{Tag: row.Tag, Uses: row.Uses})
+	}
+	respondWithJSON(writer, http.StatusOK, trends)
+}
@@ -0,0 +1,48 @@
+// Package archive packs JSON documents into a ZIP file, as used for
+// personal data exports.
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// File is one document in an archive. Data is written as indented JSON.
+type File struct {
+	Name string
+	Data interface{}
+}
+
+// Write writes files to w as a ZIP archive, stamping each entry with
+// modified. Names must be relative and unique.
+func Write(w io.Writer, files []File, modified time.Time) error {
+	archive := zip.NewWriter(w)
+	seen := map[string]bool{}
+	for _, file := range files {
+		name := path.Clean(file.Name)
+		if name != file.Name || path.IsAbs(name) || name == "." || name[0] == '.' || seen[name] {
+			return fmt.Errorf("invalid archive entry name %q", file.Name)
+		}
+		seen[name] = true
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.Data)
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", name, err)
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	modified := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	buffer := &bytes.Buffer{}
+	err := Write(buffer, []File{
+		{Name: "profile.json", Data: map[string]string{"email": "a@example.com"}},
+		{Name: "chirps.json", Data: []string{"one", "two"}},
+	}, modified)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	if len(reader.File) != 2 || reader.File[0].Name != "profile.json" || reader.File[1].Name != "chirps.json" {
+		t.Fatalf("archive entries = %v", reader.File)
+	}
+	if !reader.File[0].Modified.Equal(modified) {
+		t.Errorf("entry modified = %v, want %v", reader.File[0].Modified, modified)
+	}
+
+	entry, err := reader.File[1].Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(entry)
+	chirps := []string{}
+	if err := json.Unmarshal(data, &chirps); err != nil || len(chirps) != 2 || chirps[1] != "two" {
+		t.Errorf("chirps.json = %s, err = %v", data, err)
+	}
+}
+
+func TestWriteRejectsBadNames(t *testing.T) {
+	for _, name := range []string{"", "../escape.json", "/abs.json", "a/../b.json", ".hidden"} {
+		err := Write(io.Discard, []File{{Name: name, Data: 1}}, time.Now())
+		if err == nil {
+			t.Errorf("Write() accepted name %q", name)
+		}
+	}
+	err := Write(io.Discard, []File{{Name: "a.json", Data: 1}, {Name: "a.json", Data: 2}}, time.Now())
+	if err == nil {
+		t.Error("Write() accepted a duplicate name")
+	}
+}
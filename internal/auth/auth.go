@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -27,42 +28,108 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+const (
+	accessIssuer = "chirpy"
+	mfaIssuer    = "chirpy-mfa"
+)
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// MakeJWT issues an access token carrying every user scope.
 func MakeJWT(
 	userID uuid.UUID,
 	tokenSecret string,
 	expiresIn time.Duration,
+) (string, error) {
+	return makeJWT(userID, tokenSecret, expiresIn, accessIssuer, DefaultScopes)
+}
+
+// MakeScopedJWT issues an access token limited to the given scopes.
+func MakeScopedJWT(
+	userID uuid.UUID,
+	tokenSecret string,
+	expiresIn time.Duration,
+	scopes []string,
+) (string, error) {
+	return makeJWT(userID, tokenSecret, expiresIn, accessIssuer, scopes)
+}
+
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	id, _, err := validateJWT(tokenString, tokenSecret, accessIssuer)
+	return id, err
+}
+
+// ValidateScopedJWT also returns the token's scopes. A token with no scope
+// claim, including one issued before scopes existed, is granted none.
+func ValidateScopedJWT(tokenString, tokenSecret string) (uuid.UUID, []string, error) {
+	return validateJWT(tokenString, tokenSecret, accessIssuer)
+}
+
+// MakeMFAToken issues the challenge token handed out after a correct password
+// when the user has TOTP enabled. Its issuer differs from access tokens so it
+// can't be used in their place. The scopes requested at login ride along so
+// they apply to the session issued once the challenge is passed.
+func MakeMFAToken(
+	userID uuid.UUID,
+	tokenSecret string,
+	expiresIn time.Duration,
+	scopes []string,
+) (string, error) {
+	return makeJWT(userID, tokenSecret, expiresIn, mfaIssuer, scopes)
+}
+
+func ValidateMFAToken(tokenString, tokenSecret string) (uuid.UUID, []string, error) {
+	return validateJWT(tokenString, tokenSecret, mfaIssuer)
+}
+
+func makeJWT(
+	userID uuid.UUID,
+	tokenSecret string,
+	expiresIn time.Duration,
+	issuer string,
+	scopes []string,
 ) (string, error) {
 	signingKey := []byte(tokenSecret)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		Scope: strings.Join(scopes, " "),
 	})
 	return token.SignedString(signingKey)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
+func validateJWT(tokenString, tokenSecret, issuer string) (uuid.UUID, []string, error) {
+	claimsStruct := tokenClaims{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&claimsStruct,
 		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
+		jwt.WithIssuer(issuer),
 	)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, nil, err
 	}
 
 	userIDString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, nil, err
 	}
 
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return uuid.Nil, nil, fmt.Errorf("invalid user ID: %w", err)
 	}
-	return id, nil
+
+	// An empty claim grants nothing; defaults are resolved when the token is
+	// issued, never when it's read.
+	return id, strings.Fields(claimsStruct.Scope), nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -86,10 +153,32 @@ func MakeRefreshToken() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
 func GetAPIKey(headers http.Header) (string, error) {
-	authorizationHeader := headers.Get("Authorization")
-	authHeaderCleaned, _ := strings.CutPrefix(
-		strings.ReplaceAll(authorizationHeader, " ", ""),
-		"ApiKey")
-	return authHeaderCleaned, nil
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
+		return "", errors.New("no api key")
+	}
+	scheme, key, found := strings.Cut(authHeader, " ")
+	key = strings.TrimSpace(key)
+	if !found || scheme != "ApiKey" || key == "" {
+		return "", errors.New("malformed authorization header")
+	}
+	return key, nil
+}
+
+// MakeAPIKey returns a personal API key. The prefix makes leaked keys easy to
+// recognise in logs and secret scanners.
+func MakeAPIKey() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return "chirpy_" + hex.EncodeToString(bytes), nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
 }
@@ -154,3 +154,97 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateScopedJWT(t *testing.T) {
+	userID := uuid.New()
+	scopedToken, _ := MakeScopedJWT(userID, "secret", time.Hour, []string{ScopeChirpsRead})
+	fullToken, _ := MakeJWT(userID, "secret", time.Hour)
+	unscopedToken, _ := MakeScopedJWT(userID, "secret", time.Hour, nil)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		wantScopes  []string
+	}{
+		{
+			name:        "Scoped token",
+			tokenString: scopedToken,
+			wantScopes:  []string{ScopeChirpsRead},
+		},
+		{
+			name:        "Full token",
+			tokenString: fullToken,
+			wantScopes:  DefaultScopes,
+		},
+		{
+			name:        "Empty scope claim",
+			tokenString: unscopedToken,
+			wantScopes:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gotScopes, err := ValidateScopedJWT(tt.tokenString, "secret")
+			if err != nil {
+				t.Fatalf("ValidateScopedJWT() error = %v", err)
+			}
+			if MissingScope(gotScopes, tt.wantScopes...) != "" || len(gotScopes) != len(tt.wantScopes) {
+				t.Errorf("ValidateScopedJWT() gotScopes = %v, want %v", gotScopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestGetAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		wantKey string
+		wantErr bool
+	}{
+		{
+			name: "Valid ApiKey header",
+			headers: http.Header{
+				"Authorization": []string{"ApiKey abc 123"},
+			},
+			wantKey: "abc 123",
+			wantErr: false,
+		},
+		{
+			name:    "Missing Authorization header",
+			headers: http.Header{},
+			wantKey: "",
+			wantErr: true,
+		},
+		{
+			name: "Bearer scheme",
+			headers: http.Header{
+				"Authorization": []string{"Bearer token"},
+			},
+			wantKey: "",
+			wantErr: true,
+		},
+		{
+			name: "Empty key",
+			headers: http.Header{
+				"Authorization": []string{"ApiKey "},
+			},
+			wantKey: "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey, err := GetAPIKey(tt.headers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotKey != tt.wantKey {
+				t.Errorf("GetAPIKey() gotKey = %v, want %v", gotKey, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import "slices"
+
+const (
+	ScopeChirpsRead    = "chirps:read"
+	ScopeChirpsWrite   = "chirps:write"
+	ScopeUsersWrite    = "users:write"
+	ScopeKeysWrite     = "keys:write"
+	ScopeWebhooksWrite = "webhooks:write"
+)
+
+var KnownScopes = []string{
+	ScopeChirpsRead,
+	ScopeChirpsWrite,
+	ScopeUsersWrite,
+	ScopeKeysWrite,
+	ScopeWebhooksWrite,
+}
+
+// DefaultScopes are granted to a login that doesn't name any scopes.
+var DefaultScopes = KnownScopes
+
+func ValidScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(KnownScopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingScope returns the first required scope not in granted, or "" when
+// every one is present.
+func MissingScope(granted []string, required ...string) string {
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			return scope
+		}
+	}
+	return ""
+}
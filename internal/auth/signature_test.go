@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"user.upgraded"}`)
+	secrets := []string{"new_secret", "old_secret"}
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:    "Current secret",
+			header:  SignPayload("new_secret", now, body),
+			body:    body,
+			wantErr: nil,
+		},
+		{
+			name:    "Rotated out secret still active",
+			header:  SignPayload("old_secret", now, body),
+			body:    body,
+			wantErr: nil,
+		},
+		{
+			name:    "Unknown secret",
+			header:  SignPayload("other_secret", now, body),
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "Tampered body",
+			header:  SignPayload("new_secret", now, body),
+			body:    []byte(`{"event":"user.downgraded"}`),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "Replayed old delivery",
+			header:  SignPayload("new_secret", now.Add(-10*time.Minute), body),
+			body:    body,
+			wantErr: ErrStaleSignature,
+		},
+		{
+			name:    "Missing header",
+			header:  "",
+			body:    body,
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:    "Malformed header",
+			header:  "garbage",
+			body:    body,
+			wantErr: ErrMalformedSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.header, tt.body, secrets, 5*time.Minute, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RFC 6238 test secret "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		at       time.Time
+		wantCode string
+	}{
+		{
+			name:     "RFC vector 59",
+			at:       time.Unix(59, 0),
+			wantCode: "287082",
+		},
+		{
+			name:     "RFC vector 1111111109",
+			at:       time.Unix(1111111109, 0),
+			wantCode: "081804",
+		},
+		{
+			name:     "RFC vector 2000000000",
+			at:       time.Unix(2000000000, 0),
+			wantCode: "279037",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCode, err := TOTPCode(rfcSecret, tt.at)
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("TOTPCode() gotCode = %v, want %v", gotCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := TOTPCode(rfcSecret, now.Add(-30*time.Second))
+	stale, _ := TOTPCode(rfcSecret, now.Add(-90*time.Second))
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{
+			name:    "Current code",
+			code:    "081804",
+			wantErr: false,
+		},
+		{
+			name:    "Previous period within skew",
+			code:    previous,
+			wantErr: false,
+		},
+		{
+			name:    "Code outside skew",
+			code:    stale,
+			wantErr: true,
+		},
+		{
+			name:    "Wrong length",
+			code:    "81804",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTOTP(tt.code, rfcSecret, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := TOTPCode(rfcSecret, now.Add(-30*time.Second))
+
+	gotStep, err := MatchTOTP("081804", rfcSecret, now)
+	if err != nil || gotStep != 1111111109/30 {
+		t.Errorf("MatchTOTP() gotStep = %v, err = %v, want %v", gotStep, err, 1111111109/30)
+	}
+	gotStep, err = MatchTOTP(previous, rfcSecret, now)
+	if err != nil || gotStep != 1111111109/30-1 {
+		t.Errorf("MatchTOTP() gotStep = %v, err = %v, want %v", gotStep, err, 1111111109/30-1)
+	}
+}
+
+func TestMFATokenNotAccessToken(t *testing.T) {
+	userID := uuid.New()
+	mfaToken, _ := MakeMFAToken(userID, "secret", time.Minute, []string{ScopeChirpsWrite})
+	accessToken, _ := MakeJWT(userID, "secret", time.Minute)
+
+	if _, err := ValidateJWT(mfaToken, "secret"); err == nil {
+		t.Errorf("ValidateJWT() accepted an MFA challenge token")
+	}
+	if _, _, err := ValidateMFAToken(accessToken, "secret"); err == nil {
+		t.Errorf("ValidateMFAToken() accepted an access token")
+	}
+	gotUserID, gotScopes, err := ValidateMFAToken(mfaToken, "secret")
+	if err != nil || gotUserID != userID {
+		t.Errorf("ValidateMFAToken() gotUserID = %v, err = %v", gotUserID, err)
+	}
+	if len(gotScopes) != 1 || gotScopes[0] != ScopeChirpsWrite {
+		t.Errorf("ValidateMFAToken() gotScopes = %v, want [%v]", gotScopes, ScopeChirpsWrite)
+	}
+}
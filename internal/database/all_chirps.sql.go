@@ -12,7 +12,7 @@ import (
 )
 
 const allChirps = `-- name: AllChirps :many
-select id, created_at, updated_at, body, user_id from chirps order by created_at
+select id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id from chirps where deleted_at is null order by created_at
 `
 
 func (q *Queries) AllChirps(ctx context.Context) ([]Chirp, error) {
@@ -30,6 +30,10 @@ func (q *Queries) AllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ReplyToID,
+			&i.Entities,
+			&i.DeletedAt,
+			&i.RepostOfID,
 		); err != nil {
 			return nil, err
 		}
@@ -45,7 +49,7 @@ func (q *Queries) AllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const allChirpsAuthorID = `-- name: AllChirpsAuthorID :many
-select id, created_at, updated_at, body, user_id from chirps where user_id = $1
+select id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id from chirps where user_id = $1 and deleted_at is null
 `
 
 func (q *Queries) AllChirpsAuthorID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -63,6 +67,10 @@ func (q *Queries) AllChirpsAuthorID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ReplyToID,
+			&i.Entities,
+			&i.DeletedAt,
+			&i.RepostOfID,
 		); err != nil {
 			return nil, err
 		}
@@ -9,14 +9,31 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const checkRefresh = `-- name: CheckRefresh :one
-select user_id from refresh_tokens where token = $1 and expires_at < NOW() and revoked_at is null
+select user_id, scopes from refresh_tokens where token = $1 and expires_at > NOW() and revoked_at is null
 `
 
-func (q *Queries) CheckRefresh(ctx context.Context, token string) (uuid.UUID, error) {
+type CheckRefreshRow struct {
+	UserID uuid.UUID
+	Scopes []string
+}
+
+func (q *Queries) CheckRefresh(ctx context.Context, token string) (CheckRefreshRow, error) {
 	row := q.db.QueryRowContext(ctx, checkRefresh, token)
+	var i CheckRefreshRow
+	err := row.Scan(&i.UserID, pq.Array(&i.Scopes))
+	return i, err
+}
+
+const refreshTokenUser = `-- name: RefreshTokenUser :one
+select user_id from refresh_tokens where token = $1
+`
+
+func (q *Queries) RefreshTokenUser(ctx context.Context, token string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, refreshTokenUser, token)
 	var user_id uuid.UUID
 	err := row.Scan(&user_id)
 	return user_id, err
@@ -7,23 +7,33 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, entities, repost_of_id)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+RETURNING id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	ReplyToID  uuid.NullUUID
+	Entities   json.RawMessage
+	RepostOfID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ReplyToID,
+		arg.Entities,
+		arg.RepostOfID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +41,10 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ReplyToID,
+		&i.Entities,
+		&i.DeletedAt,
+		&i.RepostOfID,
 	)
 	return i, err
 }
@@ -10,22 +10,29 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createRefresh = `-- name: CreateRefresh :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-VALUES ($1, NOW(), NOW(), $2, $3)
-RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, scopes)
+VALUES ($1, NOW(), NOW(), $2, $3, $4)
+RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id, scopes
 `
 
 type CreateRefreshParams struct {
 	Token     string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	Scopes    []string
 }
 
 func (q *Queries) CreateRefresh(ctx context.Context, arg CreateRefreshParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefresh, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefresh,
+		arg.Token,
+		arg.UserID,
+		arg.ExpiresAt,
+		pq.Array(arg.Scopes),
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -34,6 +41,7 @@ func (q *Queries) CreateRefresh(ctx context.Context, arg CreateRefreshParams) (R
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		pq.Array(&i.Scopes),
 	)
 	return i, err
 }
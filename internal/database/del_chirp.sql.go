@@ -7,10 +7,32 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const chirpIncludingDeleted = `-- name: ChirpIncludingDeleted :one
+select id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id from chirps where id = $1
+`
+
+func (q *Queries) ChirpIncludingDeleted(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, chirpIncludingDeleted, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.Entities,
+		&i.DeletedAt,
+		&i.RepostOfID,
+	)
+	return i, err
+}
+
 const delChirp = `-- name: DelChirp :exec
 
 delete from chirps where id = $1
@@ -20,3 +42,52 @@ func (q *Queries) DelChirp(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, delChirp, id)
 	return err
 }
+
+const restoreChirp = `-- name: RestoreChirp :one
+update chirps set deleted_at = NULL where id = $1 and deleted_at > $2
+returning id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id
+`
+
+type RestoreChirpParams struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.DeletedAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.Entities,
+		&i.DeletedAt,
+		&i.RepostOfID,
+	)
+	return i, err
+}
+
+const softDelChirp = `-- name: SoftDelChirp :one
+update chirps set deleted_at = NOW() where id = $1 and deleted_at is null
+returning id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id
+`
+
+func (q *Queries) SoftDelChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, softDelChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ReplyToID,
+		&i.Entities,
+		&i.DeletedAt,
+		&i.RepostOfID,
+	)
+	return i, err
+}
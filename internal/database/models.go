@@ -6,17 +6,192 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	UserID     uuid.UUID
+}
+
+type AuditLog struct {
+	ID        int64
+	CreatedAt time.Time
+	Action    string
+	ActorID   uuid.NullUUID
+	TargetID  uuid.NullUUID
+	Ip        string
+	UserAgent string
+	RequestID string
+	Details   json.RawMessage
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CreatedAt    time.Time
+	CollectionID uuid.NullUUID
+}
+
 type Chirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ReplyToID  uuid.NullUUID
+	Entities   json.RawMessage
+	DeletedAt  sql.NullTime
+	RepostOfID uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpLink struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Url      string
+}
+
+type ChirpMedium struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ChirpID     uuid.UUID
+	Position    int32
+	ContentType string
+	Width       int32
+	Height      int32
+	BlobKey     string
+	ThumbKey    string
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type ChirpReaction struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Reaction  string
+	CreatedAt time.Time
+}
+
+type ChirpStreamEvent struct {
+	ID          int64
+	CreatedAt   time.Time
+	Event       string
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	Payload     json.RawMessage
+	RecipientID uuid.NullUUID
+}
+
+type Collection struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	CompletedAt sql.NullTime
+	Archive     []byte
+}
+
+type Draft struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Body      string
+	ReplyToID uuid.NullUUID
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type HandleReservation struct {
+	Handle        string
+	UserID        uuid.UUID
+	ReservedUntil time.Time
+}
+
+type Job struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       time.Time
+	LockedAt    sql.NullTime
+	LastError   sql.NullString
+	CompletedAt sql.NullTime
+}
+
+type LinkPreview struct {
+	Url         string
+	FetchedAt   time.Time
+	Ok          bool
+	Title       string
+	Description string
+	ImageUrl    string
+}
+
+type MfaAttempt struct {
+	UserID       uuid.UUID
+	FailedCount  int32
+	LockedUntil  sql.NullTime
+	LastTotpStep int64
+}
+
+type MfaRecoveryCode struct {
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
+	UserID    uuid.UUID
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
 	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type OidcLoginState struct {
+	State        string
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
 }
 
 type RefreshToken struct {
@@ -26,13 +201,99 @@ type RefreshToken struct {
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
 	UserID    uuid.UUID
+	Scopes    []string
+}
+
+type ScheduledChirp struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Body      string
+	ReplyToID uuid.NullUUID
+	PublishAt time.Time
+}
+
+type Subscription struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Plan       string
+	Status     string
+	StartedAt  time.Time
+	ExpiresAt  sql.NullTime
+	CanceledAt sql.NullTime
+	UserID     uuid.UUID
+}
+
+type SubscriptionHistory struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Event          string
+	Status         string
+	ExpiresAt      sql.NullTime
+	SubscriptionID uuid.UUID
 }
 
 type User struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      sql.NullString
+	TotpSecret          sql.NullString
+	TotpEnabled         bool
+	IsAdmin             bool
+	Handle              sql.NullString
+	DisplayName         string
+	Bio                 string
+	AvatarURL           string
+	HandleChangedAt     sql.NullTime
+	DeletionRequestedAt sql.NullTime
+}
+
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	CreatedAt time.Time
+	Email     sql.NullString
+	UserID    uuid.UUID
+}
+
+type WebhookEvent struct {
+	ID          string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Event       string
+	Payload     json.RawMessage
+	Status      string
+	Error       sql.NullString
+	Attempts    int32
+	ProcessedAt sql.NullTime
+}
+
+type WebhookDelivery struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    sql.NullBool
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastError      sql.NullString
+	ResponseStatus sql.NullInt32
+	DeliveredAt    sql.NullTime
+	SubscriptionID uuid.UUID
+}
+
+type WebhookSubscription struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+	UserID    uuid.NullUUID
 }
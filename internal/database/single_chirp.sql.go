@@ -12,7 +12,7 @@ import (
 )
 
 const singleChirp = `-- name: SingleChirp :one
-select id, created_at, updated_at, body, user_id from chirps where id = $1
+select id, created_at, updated_at, body, user_id, reply_to_id, entities, deleted_at, repost_of_id from chirps where id = $1 and deleted_at is null
 `
 
 func (q *Queries) SingleChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -24,6 +24,10 @@ func (q *Queries) SingleChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ReplyToID,
+		&i.Entities,
+		&i.DeletedAt,
+		&i.RepostOfID,
 	)
 	return i, err
 }
@@ -14,7 +14,7 @@ import (
 
 const updatePassEmail = `-- name: UpdatePassEmail :one
 update users set email = $3, hashed_password = $2 where id = $1
-returning id, created_at, updated_at, email, hashed_password, is_chirpy_red
+returning id, created_at, updated_at, email, hashed_password, totp_secret, totp_enabled, is_admin, handle, display_name, bio, avatar_url, handle_changed_at, deletion_requested_at
 `
 
 type UpdatePassEmailParams struct {
@@ -32,7 +32,15 @@ func (q *Queries) UpdatePassEmail(ctx context.Context, arg UpdatePassEmailParams
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.HandleChangedAt,
+		&i.DeletionRequestedAt,
 	)
 	return i, err
 }
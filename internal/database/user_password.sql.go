@@ -10,7 +10,7 @@ import (
 )
 
 const userPassword = `-- name: UserPassword :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users where email = $1
+SELECT id, created_at, updated_at, email, hashed_password, totp_secret, totp_enabled, is_admin, handle, display_name, bio, avatar_url, handle_changed_at, deletion_requested_at FROM users where email = $1
 `
 
 func (q *Queries) UserPassword(ctx context.Context, email string) (User, error) {
@@ -22,7 +22,15 @@ func (q *Queries) UserPassword(ctx context.Context, email string) (User, error)
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarURL,
+		&i.HandleChangedAt,
+		&i.DeletionRequestedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,175 @@
+// Package dbtest is a database/sql driver for tests. It answers the queries
+// in internal/database by their sqlc name, so code built on database.Queries
+// can run without Postgres, and records which calls were committed.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// Rows is a handler's answer to one query. Affected is reported for
+// statements run with Exec.
+type Rows struct {
+	Columns  []string
+	Values   [][]driver.Value
+	Affected int64
+}
+
+// Handler answers a query given its arguments.
+type Handler func(args []driver.Value) (Rows, error)
+
+// Call is one query the code under test ran.
+type Call struct {
+	Name string
+	Args []driver.Value
+}
+
+// DB is a *sql.DB backed by handlers. Calls made in a transaction are only
+// recorded once it commits.
+type DB struct {
+	*sql.DB
+
+	mu        sync.Mutex
+	handlers  map[string]Handler
+	committed []Call
+}
+
+func New() *DB {
+	db := &DB{handlers: map[string]Handler{}}
+	db.DB = sql.OpenDB(connector{db})
+	return db
+}
+
+// Handle sets the handler for the query called name.
+func (db *DB) Handle(name string, handler Handler) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.handlers[name] = handler
+}
+
+// Calls returns the committed calls of the query called name, oldest first.
+func (db *DB) Calls(name string) []Call {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	calls := []Call{}
+	for _, call := range db.committed {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+func (db *DB) run(query string, named []driver.NamedValue) (Call, Rows, error) {
+	match := queryName.FindStringSubmatch(query)
+	if match == nil {
+		return Call{}, Rows{}, fmt.Errorf("dbtest: unnamed query %q", query)
+	}
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	call := Call{Name: match[1], Args: args}
+
+	db.mu.Lock()
+	handler, ok := db.handlers[call.Name]
+	db.mu.Unlock()
+	if !ok {
+		return call, Rows{}, fmt.Errorf("dbtest: no handler for %s", call.Name)
+	}
+	rows, err := handler(args)
+	return call, rows, err
+}
+
+func (db *DB) commit(calls []Call) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.committed = append(db.committed, calls...)
+}
+
+type connector struct{ db *DB }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{db: c.db}, nil }
+func (c connector) Driver() driver.Driver                        { return nil }
+
+type conn struct {
+	db      *DB
+	inTx    bool
+	pending []Call
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("dbtest: prepared statements aren't supported")
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return tx{c}, nil
+}
+
+func (c *conn) record(call Call) {
+	if c.inTx {
+		c.pending = append(c.pending, call)
+		return
+	}
+	c.db.commit([]Call{call})
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	call, result, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	c.record(call)
+	return &rows{result: result}, nil
+}
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	call, result, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	c.record(call)
+	return driver.RowsAffected(result.Affected), nil
+}
+
+type tx struct{ c *conn }
+
+func (t tx) Commit() error {
+	t.c.db.commit(t.c.pending)
+	t.c.inTx, t.c.pending = false, nil
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.c.inTx, t.c.pending = false, nil
+	return nil
+}
+
+type rows struct {
+	result Rows
+	next   int
+}
+
+func (r *rows) Columns() []string { return r.result.Columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Values) {
+		return io.EOF
+	}
+	copy(dest, r.result.Values[r.next])
+	r.next++
+	return nil
+}
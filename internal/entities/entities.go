@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeMention = "mention"
+	TypeHashtag = "hashtag"
+)
+
+// Entity is a mention or hashtag found in a chirp body. Start and End are
+// offsets in Unicode code points, End exclusive, and cover the leading @ or #.
+// Text is the normalized handle, email or tag without it.
+type Entity struct {
+	Type   string     `json:"type"`
+	Start  int        `json:"start"`
+	End    int        `json:"end"`
+	Text   string     `json:"text"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+var (
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@])(@([A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}|[A-Za-z0-9_]{1,30}))`)
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\w#&])(#([\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*))`)
+)
+
+// Parse finds the mentions and hashtags in body, ordered by position.
+// Mentions are left unresolved.
+func Parse(body string) []Entity {
+	found := []Entity{}
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		found = append(found, entity(body, TypeMention, match))
+	}
+	for _, match := range hashtagPattern.FindAllStringSubmatchIndex(body, -1) {
+		found = append(found, entity(body, TypeHashtag, match))
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Start < found[j].Start })
+	return found
+}
+
+func entity(body, kind string, match []int) Entity {
+	start, end := match[2], match[3]
+	return Entity{
+		Type:  kind,
+		Start: utf8.RuneCountInString(body[:start]),
+		End:   utf8.RuneCountInString(body[:end]),
+		Text:  strings.ToLower(body[match[4]:match[5]]),
+	}
+}
+
+// Texts returns the distinct Text values of entities of the given type.
+func Texts(found []Entity, kind string) []string {
+	seen := map[string]bool{}
+	texts := []string{}
+	for _, current := range found {
+		if current.Type == kind && !seen[current.Text] {
+			seen[current.Text] = true
+			texts = append(texts, current.Text)
+		}
+	}
+	return texts
+}
+
+// Resolve sets UserID on each mention found in users, keyed by Text, and
+// returns the distinct IDs mentioned.
+func Resolve(found []Entity, users map[string]uuid.UUID) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for i := range found {
+		if found[i].Type != TypeMention {
+			continue
+		}
+		id, ok := users[found[i].Text]
+		if !ok {
+			continue
+		}
+		found[i].UserID = &id
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "Handle and hashtag",
+			body: "hi @Alice, see #GoLang",
+			want: []Entity{
+				{Type: TypeMention, Start: 3, End: 9, Text: "alice"},
+				{Type: TypeHashtag, Start: 15, End: 22, Text: "golang"},
+			},
+		},
+		{
+			name: "Email mention",
+			body: "@bob@example.com ping",
+			want: []Entity{
+				{Type: TypeMention, Start: 0, End: 16, Text: "bob@example.com"},
+			},
+		},
+		{
+			name: "Offsets count code points",
+			body: "é #café",
+			want: []Entity{
+				{Type: TypeHashtag, Start: 2, End: 7, Text: "café"},
+			},
+		},
+		{
+			name: "Plain email and numeric tag ignored",
+			body: "mail me at carol@example.com about issue #42",
+			want: []Entity{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	alice := uuid.New()
+	found := Parse("@alice @nobody @ALICE #tag")
+
+	ids := Resolve(found, map[string]uuid.UUID{"alice": alice})
+	if len(ids) != 1 || ids[0] != alice {
+		t.Errorf("Resolve() ids = %v, want [%v]", ids, alice)
+	}
+	if found[0].UserID == nil || *found[0].UserID != alice || found[2].UserID == nil {
+		t.Errorf("Resolve() didn't set UserID on matching mentions")
+	}
+	if found[1].UserID != nil {
+		t.Errorf("Resolve() set UserID on an unknown mention")
+	}
+	if got := Texts(found, TypeMention); !reflect.DeepEqual(got, []string{"alice", "nobody"}) {
+		t.Errorf("Texts() = %v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	TierFree = "free"
+	TierRed  = "red"
+)
+
+// Set is what a tier of user is allowed to do.
+type Set struct {
+	MaxChirpLength    int  `json:"max_chirp_length"`
+	EditChirps        bool `json:"edit_chirps"`
+	RequestsPerMinute int  `json:"requests_per_minute"`
+}
+
+// Config maps each tier to its entitlements.
+type Config map[string]Set
+
+func Default() Config {
+	return Config{
+		TierFree: {
+			MaxChirpLength:    140,
+			EditChirps:        false,
+			RequestsPerMinute: 30,
+		},
+		TierRed: {
+			MaxChirpLength:    1000,
+			EditChirps:        true,
+			RequestsPerMinute: 300,
+		},
+	}
+}
+
+// Load reads a JSON file of tiers. Each tier in the file is laid over that
+// tier's defaults, so it only needs the fields it changes, and tiers the file
+// leaves out keep their defaults. An empty path returns the defaults.
+func Load(path string) (Config, error) {
+	config := Default()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fromFile := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fromFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entitlements file: %w", err)
+	}
+	for tier, raw := range fromFile {
+		set := Default()[tier]
+		err = json.Unmarshal(raw, &set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entitlements for tier %q: %w", tier, err)
+		}
+		err = set.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid entitlements for tier %q: %w", tier, err)
+		}
+		config[tier] = set
+	}
+	return config, nil
+}
+
+func (set Set) validate() error {
+	if set.MaxChirpLength <= 0 {
+		return errors.New("max_chirp_length must be positive")
+	}
+	if set.RequestsPerMinute <= 0 {
+		return errors.New("requests_per_minute must be positive")
+	}
+	return nil
+}
+
+// For returns the entitlements for a user given their Chirpy Red status.
+func (config Config) For(isChirpyRed bool) Set {
+	if isChirpyRed {
+		return config[TierRed]
+	}
+	return config[TierFree]
+}
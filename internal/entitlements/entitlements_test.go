@@ -0,0 +1,92 @@
+package entitlements
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entitlements.json")
+	os.WriteFile(path, []byte(`{"red": {"max_chirp_length": 500, "edit_chirps": true, "requests_per_minute": 100}}`), 0o600)
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		isChirpyRed bool
+		wantLength  int
+		wantEdit    bool
+	}{
+		{
+			name:        "Red from file",
+			isChirpyRed: true,
+			wantLength:  500,
+			wantEdit:    true,
+		},
+		{
+			name:        "Free falls back to default",
+			isChirpyRed: false,
+			wantLength:  140,
+			wantEdit:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := config.For(tt.isChirpyRed)
+			if got.MaxChirpLength != tt.wantLength || got.EditChirps != tt.wantEdit {
+				t.Errorf("For() = %+v, want length %v edit %v", got, tt.wantLength, tt.wantEdit)
+			}
+		})
+	}
+}
+
+func TestLoadOverlaysDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entitlements.json")
+	os.WriteFile(path, []byte(`{"red": {"max_chirp_length": 500}}`), 0o600)
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := config.For(true)
+	want := Default()[TierRed]
+	want.MaxChirpLength = 500
+	if got != want {
+		t.Errorf("For() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadRejectsLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{
+			name: "Zero chirp length",
+			file: `{"free": {"max_chirp_length": 0}}`,
+		},
+		{
+			name: "Negative rate limit",
+			file: `{"red": {"requests_per_minute": -1}}`,
+		},
+		{
+			name: "Unknown tier without limits",
+			file: `{"gold": {"edit_chirps": true}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "entitlements.json")
+			os.WriteFile(path, []byte(tt.file), 0o600)
+			if _, err := Load(path); err == nil {
+				t.Errorf("Load() accepted %s", tt.file)
+			}
+		})
+	}
+}
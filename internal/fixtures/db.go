@@ -0,0 +1,212 @@
+package fixtures
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/entities"
+	"github.com/amstein4920/chirpy-http-server/internal/handles"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DisposableMarker must be the comment on a database before anything in this
+// package will modify it:
+//
+//	COMMENT ON DATABASE chirpy_dev IS 'chirpy:disposable';
+const DisposableMarker = "chirpy:disposable"
+
+var ErrNotDisposable = errors.New("database is not marked disposable (COMMENT ON DATABASE ... IS '" + DisposableMarker + "')")
+
+// scopes maps each truncation scope to its tables. Truncation cascades, so
+// "users" empties every table holding user-owned rows and "chirps" every
+// table hanging off a chirp. The audit log is append-only and has no scope.
+var scopes = map[string][]string{
+	"users":         {"users"},
+	"chirps":        {"chirps", "chirp_stream_events", "scheduled_chirps", "drafts", "bookmarks", "chirp_reactions"},
+	"notifications": {"notifications"},
+	"jobs":          {"jobs"},
+	"webhooks":      {"webhook_events", "webhook_deliveries", "webhook_subscriptions"},
+	"previews":      {"link_previews"},
+	"exports":       {"data_exports"},
+}
+
+// Scopes lists the valid truncation scopes.
+func Scopes() []string {
+	names := make([]string, 0, len(scopes))
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckDisposable returns ErrNotDisposable unless the connected database
+// carries DisposableMarker as its comment.
+func CheckDisposable(ctx context.Context, db *sql.DB) error {
+	var comment sql.NullString
+	err := db.QueryRowContext(ctx,
+		`select shobj_description(oid, 'pg_database') from pg_database where datname = current_database()`,
+	).Scan(&comment)
+	if err != nil {
+		return err
+	}
+	if comment.String != DisposableMarker {
+		return ErrNotDisposable
+	}
+	return nil
+}
+
+// truncateSQL builds one TRUNCATE over the tables of every scope named.
+func truncateSQL(names []string) (string, error) {
+	if len(names) == 0 {
+		return "", errors.New("no scopes given")
+	}
+	tables := []string{}
+	for _, name := range names {
+		scope, ok := scopes[name]
+		if !ok {
+			return "", fmt.Errorf("unknown scope %q (valid: %s)", name, strings.Join(Scopes(), ", "))
+		}
+		for _, table := range scope {
+			quoted := pq.QuoteIdentifier(table)
+			if !slices.Contains(tables, quoted) {
+				tables = append(tables, quoted)
+			}
+		}
+	}
+	return "TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE", nil
+}
+
+// Truncate empties the tables of the named scopes, after checking the
+// database is disposable.
+func Truncate(ctx context.Context, db *sql.DB, names []string) error {
+	statement, err := truncateSQL(names)
+	if err != nil {
+		return err
+	}
+	err = CheckDisposable(ctx, db)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, statement)
+	return err
+}
+
+// Counts reports how many rows Seed created.
+type Counts struct {
+	Users   int `json:"users"`
+	Chirps  int `json:"chirps"`
+	Follows int `json:"follows"`
+}
+
+// Seed inserts the fixture with stable IDs. Run it in a transaction on a
+// disposable database; IDs already present make it fail rather than merge.
+func Seed(ctx context.Context, queries *database.Queries, fixture Fixture) (Counts, error) {
+	err := fixture.Validate()
+	if err != nil {
+		return Counts{}, err
+	}
+
+	users := map[string]uuid.UUID{}
+	for _, user := range fixture.Users {
+		hash, err := auth.HashPassword(user.Password)
+		if err != nil {
+			return Counts{}, err
+		}
+		id := UserID(user.Email)
+		_, err = queries.SeedUser(ctx, database.SeedUserParams{
+			ID:             id,
+			CreatedAt:      Epoch,
+			Email:          user.Email,
+			HashedPassword: sql.NullString{String: hash, Valid: true},
+			Handle:         sql.NullString{String: user.Handle, Valid: user.Handle != ""},
+			DisplayName:    user.DisplayName,
+			Bio:            user.Bio,
+			IsAdmin:        user.Admin,
+		})
+		if err != nil {
+			return Counts{}, fmt.Errorf("seeding user %s: %w", user.Email, err)
+		}
+		if user.ChirpyRed {
+			_, err = queries.CreateSubscription(ctx, database.CreateSubscriptionParams{
+				Plan:   "chirpy_red",
+				UserID: id,
+			})
+			if err != nil {
+				return Counts{}, fmt.Errorf("seeding subscription for %s: %w", user.Email, err)
+			}
+		}
+		users[strings.ToLower(user.Email)] = id
+		if user.Handle != "" {
+			users[handles.Normalize(user.Handle)] = id
+		}
+	}
+
+	for i, chirp := range fixture.Chirps {
+		key := chirp.key(i)
+		createdAt := Epoch.Add(time.Duration(i) * time.Minute)
+		if chirp.At != nil {
+			createdAt = chirp.At.UTC()
+		}
+		replyTo := uuid.NullUUID{}
+		if chirp.ReplyTo != "" {
+			replyTo = uuid.NullUUID{UUID: ChirpID(chirp.ReplyTo), Valid: true}
+		}
+
+		found := entities.Parse(chirp.Body)
+		mentioned := entities.Resolve(found, users)
+		tags := entities.Texts(found, entities.TypeHashtag)
+		data, err := json.Marshal(found)
+		if err != nil {
+			return Counts{}, err
+		}
+
+		id := ChirpID(key)
+		_, err = queries.SeedChirp(ctx, database.SeedChirpParams{
+			ID:        id,
+			CreatedAt: createdAt,
+			Body:      chirp.Body,
+			UserID:    users[handles.Normalize(chirp.Author)],
+			ReplyToID: replyTo,
+			Entities:  data,
+		})
+		if err != nil {
+			return Counts{}, fmt.Errorf("seeding chirp %s: %w", key, err)
+		}
+		if len(tags) > 0 {
+			err = queries.AddChirpHashtags(ctx, database.AddChirpHashtagsParams{ChirpID: id, Tags: tags})
+			if err != nil {
+				return Counts{}, err
+			}
+		}
+		if len(mentioned) > 0 {
+			err = queries.AddChirpMentions(ctx, database.AddChirpMentionsParams{ChirpID: id, UserIds: mentioned})
+			if err != nil {
+				return Counts{}, err
+			}
+		}
+	}
+
+	for _, follow := range fixture.Follows {
+		err = queries.SeedFollow(ctx, database.SeedFollowParams{
+			FollowerID: users[handles.Normalize(follow.Follower)],
+			FolloweeID: users[handles.Normalize(follow.Followee)],
+			CreatedAt:  Epoch,
+		})
+		if err != nil {
+			return Counts{}, fmt.Errorf("seeding follow of %s by %s: %w", follow.Followee, follow.Follower, err)
+		}
+	}
+
+	return Counts{Users: len(fixture.Users), Chirps: len(fixture.Chirps), Follows: len(fixture.Follows)}, nil
+}
@@ -0,0 +1,157 @@
+// Package fixtures resets and seeds development databases. Everything here
+// refuses to touch a database that hasn't been marked disposable.
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/handles"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Epoch is the default creation time of seeded rows. Chirps without an
+// explicit time are spaced a minute apart from it, in file order.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// namespace scopes the name-based UUIDs given to seeded rows.
+var namespace = uuid.MustParse("6f1c3c5e-56a4-4c1b-9a55-2a7b8f0c4e11")
+
+// Fixture is a seed file.
+type Fixture struct {
+	Users   []User   `yaml:"users"`
+	Chirps  []Chirp  `yaml:"chirps"`
+	Follows []Follow `yaml:"follows"`
+}
+
+type User struct {
+	Email       string `yaml:"email"`
+	Password    string `yaml:"password"`
+	Handle      string `yaml:"handle"`
+	DisplayName string `yaml:"display_name"`
+	Bio         string `yaml:"bio"`
+	Admin       bool   `yaml:"admin"`
+	ChirpyRed   bool   `yaml:"chirpy_red"`
+}
+
+// Chirp is a seeded chirp. Author is the email or handle of a seeded user.
+// Key names the chirp so later ones can reply to it; it defaults to the
+// chirp's position in the file.
+type Chirp struct {
+	Key     string     `yaml:"key"`
+	Author  string     `yaml:"author"`
+	Body    string     `yaml:"body"`
+	At      *time.Time `yaml:"at"`
+	ReplyTo string     `yaml:"reply_to"`
+}
+
+// Follow makes Follower follow Followee, each the email or handle of a seeded
+// user.
+type Follow struct {
+	Follower string `yaml:"follower"`
+	Followee string `yaml:"followee"`
+}
+
+// Parse reads and validates a YAML fixture. Unknown fields are errors so
+// typos don't silently seed less than intended.
+func Parse(r io.Reader) (Fixture, error) {
+	fixture := Fixture{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	err := decoder.Decode(&fixture)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Fixture{}, fmt.Errorf("parsing fixture: %w", err)
+	}
+	return fixture, fixture.Validate()
+}
+
+// Validate checks that users are unique and that every chirp's author and
+// reply target exist. Replies must come after the chirp they reply to.
+// Follows must name two different seeded users and each pair appear once.
+func (fixture Fixture) Validate() error {
+	users := map[string]uuid.UUID{}
+	for i, user := range fixture.Users {
+		email := strings.ToLower(user.Email)
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("user %d: invalid email %q", i, user.Email)
+		}
+		if user.Password == "" {
+			return fmt.Errorf("user %s: password is required", user.Email)
+		}
+		if _, ok := users[email]; ok {
+			return fmt.Errorf("user %s: duplicate email", user.Email)
+		}
+		users[email] = UserID(user.Email)
+		if user.Handle != "" {
+			err := handles.Validate(user.Handle)
+			if err != nil {
+				return fmt.Errorf("user %s: %w", user.Email, err)
+			}
+			handle := handles.Normalize(user.Handle)
+			if _, ok := users[handle]; ok {
+				return fmt.Errorf("user %s: duplicate handle %q", user.Email, user.Handle)
+			}
+			users[handle] = UserID(user.Email)
+		}
+	}
+
+	keys := map[string]bool{}
+	for i, chirp := range fixture.Chirps {
+		key := chirp.key(i)
+		if keys[key] {
+			return fmt.Errorf("chirp %s: duplicate key", key)
+		}
+		if _, ok := users[handles.Normalize(chirp.Author)]; !ok {
+			return fmt.Errorf("chirp %s: unknown author %q", key, chirp.Author)
+		}
+		if chirp.Body == "" {
+			return fmt.Errorf("chirp %s: body is required", key)
+		}
+		if chirp.ReplyTo != "" && !keys[chirp.ReplyTo] {
+			return fmt.Errorf("chirp %s: reply_to %q must name an earlier chirp", key, chirp.ReplyTo)
+		}
+		keys[key] = true
+	}
+
+	follows := map[[2]uuid.UUID]bool{}
+	for i, follow := range fixture.Follows {
+		follower, ok := users[handles.Normalize(follow.Follower)]
+		if !ok {
+			return fmt.Errorf("follow %d: unknown follower %q", i, follow.Follower)
+		}
+		followee, ok := users[handles.Normalize(follow.Followee)]
+		if !ok {
+			return fmt.Errorf("follow %d: unknown followee %q", i, follow.Followee)
+		}
+		if follower == followee {
+			return fmt.Errorf("follow %d: %q can't follow themselves", i, follow.Follower)
+		}
+		pair := [2]uuid.UUID{follower, followee}
+		if follows[pair] {
+			return fmt.Errorf("follow %d: duplicate follow of %q by %q", i, follow.Followee, follow.Follower)
+		}
+		follows[pair] = true
+	}
+	return nil
+}
+
+func (chirp Chirp) key(index int) string {
+	if chirp.Key != "" {
+		return chirp.Key
+	}
+	return fmt.Sprint(index)
+}
+
+// UserID is the ID a user seeded with email gets, the same on every run.
+func UserID(email string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte("user:"+strings.ToLower(email)))
+}
+
+// ChirpID is the ID a chirp seeded under key gets.
+func ChirpID(key string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte("chirp:"+key))
+}
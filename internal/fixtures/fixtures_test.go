@@ -0,0 +1,140 @@
+package fixtures
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+)
+
+const seed = `
+users:
+  - email: Alice@example.com
+    password: hunter22
+    handle: alice
+    admin: true
+  - email: bob@example.com
+    password: hunter22
+chirps:
+  - key: hello
+    author: "@Alice"
+    body: "hello #chirpy"
+  - author: bob@example.com
+    body: "@alice hi"
+    reply_to: hello
+follows:
+  - follower: bob@example.com
+    followee: "@alice"
+`
+
+func TestParse(t *testing.T) {
+	fixture, err := Parse(strings.NewReader(seed))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(fixture.Users) != 2 || len(fixture.Chirps) != 2 || len(fixture.Follows) != 1 || !fixture.Users[0].Admin {
+		t.Errorf("Parse() = %+v", fixture)
+	}
+	if fixture.Chirps[1].key(1) != "1" || fixture.Chirps[0].key(0) != "hello" {
+		t.Errorf("chirp keys = %q, %q", fixture.Chirps[0].key(0), fixture.Chirps[1].key(1))
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":    "users:\n  - email: a@example.com\n    password: x\n    pasword: y\n",
+		"duplicate email":  "users:\n  - {email: a@example.com, password: x}\n  - {email: A@example.com, password: x}\n",
+		"missing password": "users:\n  - {email: a@example.com}\n",
+		"reserved handle":  "users:\n  - {email: a@example.com, password: x, handle: admin}\n",
+		"unknown author":   "chirps:\n  - {author: nobody, body: hi}\n",
+		"forward reply":    "users:\n  - {email: a@example.com, password: x}\nchirps:\n  - {author: a@example.com, body: hi, reply_to: later}\n  - {key: later, author: a@example.com, body: yo}\n",
+		"unknown follower": "users:\n  - {email: a@example.com, password: x}\nfollows:\n  - {follower: b@example.com, followee: a@example.com}\n",
+		"self follow":      "users:\n  - {email: a@example.com, password: x, handle: aye}\nfollows:\n  - {follower: a@example.com, followee: \"@aye\"}\n",
+		"duplicate follow": "users:\n  - {email: a@example.com, password: x}\n  - {email: b@example.com, password: x, handle: bee}\nfollows:\n  - {follower: a@example.com, followee: b@example.com}\n  - {follower: A@example.com, followee: bee}\n",
+		"malformed":        "users: [",
+	}
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: Parse() succeeded", name)
+		}
+	}
+}
+
+func TestIDsAreStable(t *testing.T) {
+	if UserID("Alice@example.com") != UserID("alice@example.com") {
+		t.Error("UserID() depends on email case")
+	}
+	if UserID("a@example.com") == UserID("b@example.com") || ChirpID("1") == ChirpID("2") {
+		t.Error("IDs collide")
+	}
+	if ChirpID("hello").String() != ChirpID("hello").String() {
+		t.Error("ChirpID() isn't deterministic")
+	}
+}
+
+func TestTruncateSQL(t *testing.T) {
+	statement, err := truncateSQL([]string{"chirps", "webhooks", "chirps"})
+	if err != nil {
+		t.Fatalf("truncateSQL() error = %v", err)
+	}
+	want := `TRUNCATE "chirps", "chirp_stream_events", "scheduled_chirps", "drafts", "bookmarks", "chirp_reactions", "webhook_events", "webhook_deliveries", "webhook_subscriptions" RESTART IDENTITY CASCADE`
+	if statement != want {
+		t.Errorf("truncateSQL() = %s, want %s", statement, want)
+	}
+	if _, err := truncateSQL([]string{"users; drop table users"}); err == nil {
+		t.Error("truncateSQL() accepted an unknown scope")
+	}
+	if _, err := truncateSQL([]string{"audit"}); err == nil {
+		t.Error("truncateSQL() accepted the audit log")
+	}
+	if _, err := truncateSQL(nil); err == nil {
+		t.Error("truncateSQL() accepted no scopes")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	db := dbtest.New()
+	db.Handle("SeedUser", func(args []driver.Value) (dbtest.Rows, error) {
+		// id, created_at, email, hashed_password, handle, display_name, bio, is_admin
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "email", "hashed_password", "totp_secret", "totp_enabled",
+				"is_admin", "handle", "display_name", "bio", "avatar_url", "handle_changed_at", "deletion_requested_at"},
+			Values: [][]driver.Value{{args[0], args[1], args[1], args[2], args[3], nil, false, args[7], args[4], args[5], args[6], "", nil, nil}},
+		}, nil
+	})
+	db.Handle("SeedChirp", func(args []driver.Value) (dbtest.Rows, error) {
+		// id, created_at, body, user_id, reply_to_id, entities
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "body", "user_id", "reply_to_id", "entities", "deleted_at", "repost_of_id"},
+			Values:  [][]driver.Value{{args[0], args[1], args[1], args[2], args[3], args[4], args[5], nil, nil}},
+		}, nil
+	})
+	for _, name := range []string{"AddChirpHashtags", "AddChirpMentions", "SeedFollow"} {
+		db.Handle(name, func([]driver.Value) (dbtest.Rows, error) { return dbtest.Rows{Affected: 1}, nil })
+	}
+
+	fixture, err := Parse(strings.NewReader(seed))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	counts, err := Seed(context.Background(), database.New(db), fixture)
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	if counts != (Counts{Users: 2, Chirps: 2, Follows: 1}) {
+		t.Errorf("Seed() = %+v", counts)
+	}
+
+	calls := db.Calls("SeedFollow")
+	if len(calls) != 1 {
+		t.Fatalf("SeedFollow called %d times, want 1", len(calls))
+	}
+	// follower_id, followee_id, created_at
+	follow := calls[0].Args
+	if follow[0] != UserID("bob@example.com").String() || follow[1] != UserID("alice@example.com").String() || follow[2] != Epoch {
+		t.Errorf("SeedFollow args = %v", follow)
+	}
+}
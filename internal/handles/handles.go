@@ -0,0 +1,65 @@
+package handles
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// ChangeCooldown is how long a user must wait between handle changes.
+	ChangeCooldown = 30 * 24 * time.Hour
+
+	// ReservationPeriod is how long a released handle stays reserved for
+	// the user who gave it up.
+	ReservationPeriod = 90 * 24 * time.Hour
+)
+
+var (
+	ErrInvalid  = errors.New("handles must be 3-15 letters, digits or underscores")
+	ErrReserved = errors.New("handle is reserved")
+)
+
+var pattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
+// reserved holds words that would be confusing as handles, including path
+// segments that share /api/users/ with profiles.
+var reserved = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"chirpy":        true,
+	"help":          true,
+	"me":            true,
+	"mfa":           true,
+	"moderator":     true,
+	"profile":       true,
+	"root":          true,
+	"subscription":  true,
+	"support":       true,
+	"system":        true,
+}
+
+// Validate checks handle's format and that it isn't a reserved word. Handles
+// are compared case-insensitively but stored as the user typed them.
+func Validate(handle string) error {
+	if !pattern.MatchString(handle) {
+		return ErrInvalid
+	}
+	if reserved[Normalize(handle)] {
+		return ErrReserved
+	}
+	return nil
+}
+
+func Normalize(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// CanChange reports whether a handle last changed at lastChanged may change
+// again at now, and if not, when it can.
+func CanChange(lastChanged, now time.Time) (bool, time.Time) {
+	next := lastChanged.Add(ChangeCooldown)
+	return !now.Before(next), next
+}
@@ -0,0 +1,61 @@
+package handles
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  string
+		wantErr error
+	}{
+		{
+			name:    "Valid",
+			handle:  "Chirp_Fan99",
+			wantErr: nil,
+		},
+		{
+			name:    "Too short",
+			handle:  "ab",
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "Too long",
+			handle:  "abcdefghijklmnop",
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "Punctuation",
+			handle:  "chirp.fan",
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "Reserved in any case",
+			handle:  "Admin",
+			wantErr: ErrReserved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.handle); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanChange(t *testing.T) {
+	changed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	allowed, next := CanChange(changed, changed.Add(time.Hour))
+	if allowed || !next.Equal(changed.Add(ChangeCooldown)) {
+		t.Errorf("CanChange() = %v, %v within cooldown", allowed, next)
+	}
+	allowed, _ = CanChange(changed, changed.Add(ChangeCooldown))
+	if !allowed {
+		t.Errorf("CanChange() = false after cooldown")
+	}
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+
+	defaultMaxAttempts = 10
+	baseRetryDelay     = 10 * time.Second
+	maxRetryDelay      = time.Hour
+)
+
+// Handler runs one job. Returning an error schedules a retry.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Enqueue adds a job through queries, which should be bound to the
+// transaction making the business change so both commit or neither does.
+func Enqueue(ctx context.Context, queries *database.Queries, kind string, payload interface{}, runAt time.Time) (database.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return database.Job{}, fmt.Errorf("marshaling %s payload: %w", kind, err)
+	}
+	return queries.CreateJob(ctx, database.CreateJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       runAt.UTC(),
+	})
+}
+
+// Runner is an in-process worker pool. Jobs are claimed with SKIP LOCKED, so
+// runners in several replicas can share one table.
+type Runner struct {
+	Queries      *database.Queries
+	Workers      int
+	PollInterval time.Duration
+
+	handlers map[string]Handler
+}
+
+func NewRunner(queries *database.Queries, workers int) *Runner {
+	return &Runner{
+		Queries:      queries,
+		Workers:      workers,
+		PollInterval: time.Second,
+		handlers:     map[string]Handler{},
+	}
+}
+
+func (runner *Runner) Register(kind string, handler Handler) {
+	runner.handlers[kind] = handler
+}
+
+// Run starts the workers and blocks until ctx is cancelled and they finish.
+func (runner *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range runner.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (runner *Runner) work(ctx context.Context) {
+	for {
+		ran, err := runner.RunOne(ctx)
+		if err != nil {
+			fmt.Printf("Couldn't claim job: %s\n", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(runner.PollInterval):
+		}
+	}
+}
+
+// RunOne claims and runs a single due job, reporting whether there was one.
+func (runner *Runner) RunOne(ctx context.Context) (bool, error) {
+	job, err := runner.Queries.ClaimJob(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	handler, ok := runner.handlers[job.Kind]
+	if !ok {
+		err = fmt.Errorf("no handler for job kind %q", job.Kind)
+	} else {
+		err = runSafely(ctx, handler, job.Payload)
+	}
+
+	if err == nil {
+		err = runner.Queries.CompleteJob(ctx, job.ID)
+		if err != nil {
+			fmt.Printf("Couldn't complete job %s: %s\n", job.ID, err)
+		}
+		return true, nil
+	}
+
+	status := StatusPending
+	if job.Attempts >= job.MaxAttempts {
+		status = StatusDead
+	}
+	failErr := runner.Queries.FailJob(ctx, database.FailJobParams{
+		ID:        job.ID,
+		Status:    status,
+		RunAt:     time.Now().UTC().Add(RetryDelay(int(job.Attempts))),
+		LastError: sql.NullString{String: err.Error(), Valid: true},
+	})
+	if failErr != nil {
+		fmt.Printf("Couldn't record failure of job %s: %s\n", job.ID, failErr)
+	}
+	return true, nil
+}
+
+func runSafely(ctx context.Context, handler Handler, payload json.RawMessage) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// RetryDelay doubles the wait after each failed attempt, capped at an hour.
+func RetryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
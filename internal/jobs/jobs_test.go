@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{
+			name:     "First retry",
+			attempts: 1,
+			want:     10 * time.Second,
+		},
+		{
+			name:     "Doubles each attempt",
+			attempts: 3,
+			want:     40 * time.Second,
+		},
+		{
+			name:     "Capped",
+			attempts: 30,
+			want:     time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryDelay(tt.attempts); got != tt.want {
+				t.Errorf("RetryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSafely(t *testing.T) {
+	err := runSafely(context.Background(), func(context.Context, json.RawMessage) error {
+		panic("boom")
+	}, nil)
+	if err == nil {
+		t.Errorf("runSafely() swallowed a panic without an error")
+	}
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+var jobColumns = []string{"id", "created_at", "updated_at", "kind", "payload", "status", "attempts",
+	"max_attempts", "run_at", "locked_at", "last_error", "completed_at"}
+
+func jobRow(id uuid.UUID, kind string, attempts, maxAttempts int64) []driver.Value {
+	now := time.Now().UTC()
+	return []driver.Value{id.String(), now, now, kind, []byte(`{}`), StatusRunning, attempts, maxAttempts, now, now, nil, nil}
+}
+
+func TestEnqueueInTransaction(t *testing.T) {
+	db := dbtest.New()
+	db.Handle("CreateJob", func(args []driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Columns: jobColumns, Values: [][]driver.Value{jobRow(uuid.New(), args[0].(string), 0, args[2].(int64))}}, nil
+	})
+	queries := database.New(db)
+	ctx := context.Background()
+
+	tx, _ := db.Begin()
+	_, err := Enqueue(ctx, queries.WithTx(tx), "rolled.back", map[string]int{"n": 1}, time.Now())
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	tx.Rollback()
+	if calls := db.Calls("CreateJob"); len(calls) != 0 {
+		t.Errorf("CreateJob committed %d times after rollback, want 0", len(calls))
+	}
+
+	tx, _ = db.Begin()
+	_, err = Enqueue(ctx, queries.WithTx(tx), "committed", map[string]int{"n": 2}, time.Now())
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	tx.Commit()
+	calls := db.Calls("CreateJob")
+	if len(calls) != 1 {
+		t.Fatalf("CreateJob committed %d times, want 1", len(calls))
+	}
+	// kind, payload, max_attempts, run_at
+	if calls[0].Args[0] != "committed" || string(calls[0].Args[1].([]byte)) != `{"n":2}` || calls[0].Args[2] != int64(defaultMaxAttempts) {
+		t.Errorf("CreateJob args = %v", calls[0].Args)
+	}
+}
+
+func TestRunOne(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        string
+		attempts    int64
+		wantRan     bool
+		wantStatus  string
+		wantFailure bool
+	}{
+		{
+			name:       "Succeeds",
+			kind:       "ok",
+			attempts:   1,
+			wantRan:    true,
+			wantStatus: StatusSucceeded,
+		},
+		{
+			name:        "Fails and retries",
+			kind:        "fail",
+			attempts:    1,
+			wantRan:     true,
+			wantStatus:  StatusPending,
+			wantFailure: true,
+		},
+		{
+			name:        "Fails on last attempt",
+			kind:        "fail",
+			attempts:    3,
+			wantRan:     true,
+			wantStatus:  StatusDead,
+			wantFailure: true,
+		},
+		{
+			name:        "Unknown kind",
+			kind:        "unknown",
+			attempts:    1,
+			wantRan:     true,
+			wantStatus:  StatusPending,
+			wantFailure: true,
+		},
+		{
+			name:    "Nothing due",
+			wantRan: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := uuid.New()
+			db := dbtest.New()
+			db.Handle("ClaimJob", func([]driver.Value) (dbtest.Rows, error) {
+				if tt.kind == "" {
+					return dbtest.Rows{Columns: jobColumns}, nil
+				}
+				return dbtest.Rows{Columns: jobColumns, Values: [][]driver.Value{jobRow(id, tt.kind, tt.attempts, 3)}}, nil
+			})
+			db.Handle("CompleteJob", func([]driver.Value) (dbtest.Rows, error) { return dbtest.Rows{Affected: 1}, nil })
+			db.Handle("FailJob", func([]driver.Value) (dbtest.Rows, error) { return dbtest.Rows{Affected: 1}, nil })
+
+			runner := NewRunner(database.New(db), 1)
+			runner.Register("ok", func(context.Context, json.RawMessage) error { return nil })
+			runner.Register("fail", func(context.Context, json.RawMessage) error { return errors.New("boom") })
+
+			ran, err := runner.RunOne(context.Background())
+			if err != nil || ran != tt.wantRan {
+				t.Fatalf("RunOne() = %v, %v, want %v", ran, err, tt.wantRan)
+			}
+
+			completed, failed := db.Calls("CompleteJob"), db.Calls("FailJob")
+			switch {
+			case !tt.wantRan:
+				if len(completed)+len(failed) != 0 {
+					t.Errorf("RunOne() recorded %d outcomes with nothing due", len(completed)+len(failed))
+				}
+			case tt.wantFailure:
+				// id, status, run_at, last_error
+				if len(failed) != 1 || len(completed) != 0 {
+					t.Fatalf("FailJob calls = %d, CompleteJob calls = %d, want 1 and 0", len(failed), len(completed))
+				}
+				if failed[0].Args[0] != id.String() || failed[0].Args[1] != tt.wantStatus || failed[0].Args[3] == nil {
+					t.Errorf("FailJob args = %v, want status %v with an error", failed[0].Args, tt.wantStatus)
+				}
+			default:
+				if len(completed) != 1 || len(failed) != 0 || completed[0].Args[0] != id.String() {
+					t.Errorf("CompleteJob calls = %v, FailJob calls = %v, want job %v completed", completed, failed, id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	MaxBytes     = 5 << 20
+	MaxDimension = 4096
+	ThumbSize    = 320
+
+	// An animated GIF decodes every frame up front, so its frames' combined
+	// area is capped as well as each frame's dimensions.
+	MaxGIFFrames = 300
+	MaxGIFPixels = 4 * MaxDimension * MaxDimension
+
+	jpegQuality = 90
+)
+
+var (
+	ErrTooLarge    = errors.New("image is too large")
+	ErrUnsupported = errors.New("unsupported image type")
+)
+
+// Image is an upload after processing: re-encoded without metadata, with a
+// thumbnail no larger than ThumbSize on either side.
+type Image struct {
+	ContentType      string
+	Width            int
+	Height           int
+	Data             []byte
+	ThumbContentType string
+	Thumb            []byte
+}
+
+// Process checks data is an image Chirpy accepts and re-encodes it, which
+// drops EXIF and any other embedded metadata. The type is sniffed from the
+// content; whatever the client claimed is ignored.
+func Process(data []byte) (Image, error) {
+	if len(data) > MaxBytes {
+		return Image{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Image{}, ErrUnsupported
+	}
+
+	// Check dimensions before decoding so a small file can't expand into an
+	// enormous bitmap.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return Image{}, ErrTooLarge
+	}
+
+	processed := Image{ContentType: contentType}
+	var still image.Image
+	buffer := bytes.Buffer{}
+
+	switch contentType {
+	case "image/gif":
+		frames, pixels, err := gifSize(data)
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %w", err)
+		}
+		if frames > MaxGIFFrames || pixels > MaxGIFPixels {
+			return Image{}, ErrTooLarge
+		}
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %w", err)
+		}
+		err = gif.EncodeAll(&buffer, animation)
+		if err != nil {
+			return Image{}, err
+		}
+		still = animation.Image[0]
+	case "image/jpeg":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %w", err)
+		}
+		still = applyOrientation(decoded, exifOrientation(data))
+		err = jpeg.Encode(&buffer, still, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return Image{}, err
+		}
+	case "image/png":
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("invalid image: %w", err)
+		}
+		still = decoded
+		err = png.Encode(&buffer, still)
+		if err != nil {
+			return Image{}, err
+		}
+	}
+
+	processed.Data = buffer.Bytes()
+	processed.Width = still.Bounds().Dx()
+	processed.Height = still.Bounds().Dy()
+	processed.ThumbContentType, processed.Thumb, err = thumbnail(still, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+	return processed, nil
+}
+
+var errInvalidGIF = errors.New("malformed GIF")
+
+// gifSize walks a GIF's blocks without decoding any pixels, returning how
+// many frames it holds and their combined area.
+func gifSize(data []byte) (int, int64, error) {
+	if len(data) < 13 {
+		return 0, 0, errInvalidGIF
+	}
+	// Header and logical screen descriptor, then the global color table.
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames, pixels := 0, int64(0)
+	for pos < len(data) {
+		var err error
+		switch data[pos] {
+		case 0x21: // Extension: label, then data sub-blocks.
+			pos, err = skipGIFSubBlocks(data, pos+2)
+		case 0x2c: // Image descriptor, local color table, LZW code size, data.
+			if pos+10 > len(data) {
+				return 0, 0, errInvalidGIF
+			}
+			width := int64(data[pos+5]) | int64(data[pos+6])<<8
+			height := int64(data[pos+7]) | int64(data[pos+8])<<8
+			packed := data[pos+9]
+			pos += 10
+			if packed&0x80 != 0 {
+				pos += 3 << (packed&0x07 + 1)
+			}
+			pos, err = skipGIFSubBlocks(data, pos+1)
+			frames++
+			pixels += width * height
+		case 0x3b: // Trailer.
+			return frames, pixels, nil
+		default:
+			return 0, 0, errInvalidGIF
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return frames, pixels, nil
+}
+
+func skipGIFSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return pos, errInvalidGIF
+		}
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
+
+// thumbnail scales src to fit within ThumbSize, keeping JPEGs as JPEG and
+// using PNG otherwise so transparency survives.
+func thumbnail(src image.Image, contentType string) (string, []byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > ThumbSize || height > ThumbSize {
+		if width >= height {
+			width, height = ThumbSize, max(1, height*ThumbSize/width)
+		} else {
+			width, height = max(1, width*ThumbSize/height), ThumbSize
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), src, bounds, draw.Src, nil)
+
+	buffer := bytes.Buffer{}
+	if contentType == "image/jpeg" {
+		err := jpeg.Encode(&buffer, thumb, &jpeg.Options{Quality: jpegQuality})
+		return "image/jpeg", buffer.Bytes(), err
+	}
+	err := png.Encode(&buffer, thumb)
+	return "image/png", buffer.Bytes(), err
+}
+
+// Extension returns the file extension used when storing contentType.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
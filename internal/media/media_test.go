@@ -0,0 +1,152 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	buffer := bytes.Buffer{}
+	png.Encode(&buffer, img)
+	return buffer.Bytes()
+}
+
+func encodeGIF(width, height, frames int) []byte {
+	animation := &gif.GIF{}
+	for range frames {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9))
+		animation.Delay = append(animation.Delay, 10)
+	}
+	buffer := bytes.Buffer{}
+	gif.EncodeAll(&buffer, animation)
+	return buffer.Bytes()
+}
+
+// jpegWithOrientation builds a JPEG carrying an EXIF APP1 segment that sets
+// the orientation tag.
+func jpegWithOrientation(width, height, orientation int) []byte {
+	buffer := bytes.Buffer{}
+	jpeg.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height)), nil)
+	encoded := buffer.Bytes()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], uint16(orientation))
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, encoded[2:]...)
+}
+
+func TestProcessPNG(t *testing.T) {
+	processed, err := Process(encodePNG(640, 320))
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if processed.ContentType != "image/png" || processed.Width != 640 || processed.Height != 320 {
+		t.Errorf("Process() = %v %vx%v", processed.ContentType, processed.Width, processed.Height)
+	}
+
+	thumb, err := png.DecodeConfig(bytes.NewReader(processed.Thumb))
+	if err != nil || thumb.Width != ThumbSize || thumb.Height != ThumbSize/2 {
+		t.Errorf("thumbnail = %vx%v, err = %v", thumb.Width, thumb.Height, err)
+	}
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	data := jpegWithOrientation(40, 20, 6)
+	if exifOrientation(data) != 6 {
+		t.Fatalf("exifOrientation() didn't find the test tag")
+	}
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Errorf("Process() kept the EXIF segment")
+	}
+	if processed.Width != 20 || processed.Height != 40 {
+		t.Errorf("Process() = %vx%v, want rotated 20x40", processed.Width, processed.Height)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "Not an image",
+			data:    []byte("<html><body>hi</body></html>"),
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "Too wide",
+			data:    encodePNG(MaxDimension+1, 1),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "Too many bytes",
+			data:    make([]byte, MaxBytes+1),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "Too many GIF frames",
+			data:    encodeGIF(1, 1, MaxGIFFrames+1),
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data); err != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGIFSize(t *testing.T) {
+	data := encodeGIF(10, 20, 3)
+
+	frames, pixels, err := gifSize(data)
+	if err != nil {
+		t.Fatalf("gifSize() error = %v", err)
+	}
+	if frames != 3 || pixels != 600 {
+		t.Errorf("gifSize() = %v frames, %v pixels, want 3, 600", frames, pixels)
+	}
+
+	if _, _, err := gifSize(data[:len(data)/2]); err == nil {
+		t.Errorf("gifSize() accepted a truncated GIF")
+	}
+
+	got, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if got.ContentType != "image/gif" || got.Width != 10 || got.Height != 20 {
+		t.Errorf("Process() = %v %vx%v, want image/gif 10x20", got.ContentType, got.Width, got.Height)
+	}
+}
@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a minimal OpenID provider that issues one authorization code.
+type mockIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	code      string
+	challenge string
+	nonce     string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, code: "auth-code"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(writer http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(writer).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(writer http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(writer http.ResponseWriter, request *http.Request) {
+		request.ParseForm()
+		if request.Form.Get("code") != idp.code || CodeChallenge(request.Form.Get("code_verifier")) != idp.challenge {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    idp.server.URL,
+				Subject:   "external-user",
+				Audience:  jwt.ClaimStrings{"chirpy"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Nonce:         idp.nonce,
+		})
+		token.Header["kid"] = "test"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(writer).Encode(map[string]string{"id_token": signed})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func TestProviderFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := NewProvider(idp.server.URL, "chirpy", "", "http://localhost:8080/api/oidc/callback")
+	ctx := context.Background()
+
+	verifier, _ := MakeVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	idp.challenge = parsed.Query().Get("code_challenge")
+	idp.nonce = parsed.Query().Get("nonce")
+
+	tests := []struct {
+		name     string
+		code     string
+		verifier string
+		nonce    string
+		wantErr  bool
+	}{
+		{
+			name:     "Valid exchange",
+			code:     idp.code,
+			verifier: verifier,
+			nonce:    "nonce",
+			wantErr:  false,
+		},
+		{
+			name:     "Wrong verifier",
+			code:     idp.code,
+			verifier: "wrong",
+			nonce:    "nonce",
+			wantErr:  true,
+		},
+		{
+			name:     "Nonce mismatch",
+			code:     idp.code,
+			verifier: verifier,
+			nonce:    "other",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.Exchange(ctx, tt.code, tt.verifier, tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && claims.Subject != "external-user" {
+				t.Errorf("Exchange() subject = %v, want external-user", claims.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultMaxBytes = 512 << 10
+	DefaultTimeout  = 5 * time.Second
+
+	maxRedirects    = 3
+	maxFieldLength  = 300
+	maxURLsPerChirp = 4
+)
+
+var (
+	ErrBlockedAddress = errors.New("address is not publicly routable")
+	ErrNotHTML        = errors.New("response is not HTML")
+)
+
+// Preview is the OpenGraph summary of a page.
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// Fetcher retrieves pages for previews. Its client refuses to connect to
+// private, loopback and other non-public addresses, checked on the resolved
+// IP at dial time so DNS tricks and redirects can't reach internal services.
+type Fetcher struct {
+	Client   *http.Client
+	MaxBytes int64
+}
+
+// NewFetcher returns a Fetcher that only dials addresses allow accepts. Pass
+// nil to use PublicAddress.
+func NewFetcher(allow func(netip.Addr) bool) *Fetcher {
+	return &Fetcher{
+		Client: &http.Client{
+			Transport: GuardedTransport(allow, DefaultTimeout),
+			Timeout:   DefaultTimeout,
+			CheckRedirect: func(request *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				return checkScheme(request.URL)
+			},
+		},
+		MaxBytes: DefaultMaxBytes,
+	}
+}
+
+// GuardedTransport returns a transport that only dials addresses allow
+// accepts, or PublicAddress when allow is nil. The check runs on the resolved
+// IP, and proxies are ignored so it can't be sidestepped.
+func GuardedTransport(allow func(netip.Addr) bool, timeout time.Duration) *http.Transport {
+	if allow == nil {
+		allow = PublicAddress
+	}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addrPort.Addr().Unmap()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		Proxy:                 nil,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+}
+
+// CheckHost resolves host and returns ErrBlockedAddress if any address it
+// resolves to is one PublicAddress rejects. It gives early feedback when a
+// URL is registered; GuardedTransport still checks every connection.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicAddress(addr.Unmap()) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddress reports whether addr is a globally routable unicast address.
+func PublicAddress(addr netip.Addr) bool {
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkScheme(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", target.Scheme)
+	}
+	return nil
+}
+
+// Fetch downloads rawURL and extracts its OpenGraph tags, falling back to the
+// <title> and meta description.
+func (fetcher *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if err := checkScheme(target); err != nil {
+		return Preview{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	request.Header.Set("User-Agent", "Chirpy-LinkPreview/1.0")
+	request.Header.Set("Accept", "text/html")
+
+	response, err := fetcher.Client.Do(request)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, ErrNotHTML
+	}
+
+	preview := parse(io.LimitReader(response.Body, fetcher.MaxBytes))
+	preview.URL = rawURL
+	if preview.ImageURL != "" {
+		// Relative image paths resolve against the final URL after redirects.
+		image, err := response.Request.URL.Parse(preview.ImageURL)
+		if err == nil && checkScheme(image) == nil {
+			preview.ImageURL = image.String()
+		} else {
+			preview.ImageURL = ""
+		}
+	}
+	return preview, nil
+}
+
+func parse(body io.Reader) Preview {
+	preview := Preview{}
+	var title, description string
+
+	tokenizer := html.NewTokenizer(body)
+	inTitle := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finish(preview, title, description)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				key, content := "", ""
+				for _, attr := range token.Attr {
+					switch attr.Key {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = attr.Val
+					}
+				}
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image":
+					preview.ImageURL = content
+				case "description":
+					description = content
+				}
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "title" {
+				inTitle = false
+			}
+			if string(name) == "head" {
+				// Everything a preview needs lives in <head>.
+				return finish(preview, title, description)
+			}
+		}
+	}
+}
+
+// finish falls back to the page's <title> and meta description where there
+// are no OpenGraph equivalents.
+func finish(preview Preview, title, description string) Preview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = clip(preview.Title)
+	preview.Description = clip(preview.Description)
+	return preview
+}
+
+func clip(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	runes := []rune(value)
+	if len(runes) > maxFieldLength {
+		return string(runes[:maxFieldLength-1]) + "…"
+	}
+	return value
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// ExtractURLs returns the distinct http(s) URLs in body, in order, without
+// trailing punctuation.
+func ExtractURLs(body string) []string {
+	seen := map[string]bool{}
+	found := []string{}
+	for _, match := range urlPattern.FindAllString(body, -1) {
+		match = strings.TrimRight(match, ".,;:!?)]}'")
+		if seen[match] {
+			continue
+		}
+		seen[match] = true
+		found = append(found, match)
+		if len(found) == maxURLsPerChirp {
+			break
+		}
+	}
+	return found
+}
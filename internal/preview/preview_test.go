@@ -0,0 +1,159 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loopbackOnly lets tests reach an httptest server and nothing else.
+func loopbackOnly(addr netip.Addr) bool {
+	return addr.IsLoopback()
+}
+
+const page = `<!doctype html>
+<html><head>
+<title>Fallback title</title>
+<meta name="description" content="Fallback description">
+<meta property="og:title" content="  The   Real Title ">
+<meta property="og:image" content="/images/card.png">
+</head><body><p>ignored</p></body></html>`
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/moved":
+			http.Redirect(writer, request, "/article", http.StatusFound)
+		case "/article":
+			writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+			writer.Write([]byte(page))
+		case "/data.json":
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Write([]byte(`{}`))
+		default:
+			http.NotFound(writer, request)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(loopbackOnly)
+	got, err := fetcher.Fetch(context.Background(), server.URL+"/moved")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := Preview{
+		URL:         server.URL + "/moved",
+		Title:       "The Real Title",
+		Description: "Fallback description",
+		ImageURL:    server.URL + "/images/card.png",
+	}
+	if got != want {
+		t.Errorf("Fetch() = %+v, want %+v", got, want)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/data.json"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Fetch() of JSON error = %v, want %v", err, ErrNotHTML)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("Fetch() of a 404 succeeded")
+	}
+	if _, err := fetcher.Fetch(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Error("Fetch() of a file URL succeeded")
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html")
+		writer.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher(nil).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() of loopback error = %v, want %v", err, ErrBlockedAddress)
+	}
+
+	// Redirects are dialled through the same check.
+	redirector := httptest.NewServer(http.RedirectHandler("http://10.0.0.1/admin", http.StatusFound))
+	defer redirector.Close()
+	_, err = NewFetcher(loopbackOnly).Fetch(context.Background(), redirector.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Fetch() redirected to a private address error = %v, want %v", err, ErrBlockedAddress)
+	}
+}
+
+func TestFetchLimitsBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/html")
+		writer.Write([]byte("<html><head><title>Early</title>"))
+		writer.Write([]byte(strings.Repeat("<!-- padding -->", 1<<12)))
+		writer.Write([]byte(`<meta property="og:title" content="Too late"></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(loopbackOnly)
+	fetcher.MaxBytes = 1 << 10
+	got, err := fetcher.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got.Title != "Early" {
+		t.Errorf("Fetch() title = %q, want %q", got.Title, "Early")
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"224.0.0.1":       false,
+	}
+	for input, want := range tests {
+		if got := PublicAddress(netip.MustParseAddr(input)); got != want {
+			t.Errorf("PublicAddress(%s) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"169.254.169.254": false,
+		"::1":             false,
+	}
+	for host, want := range tests {
+		err := CheckHost(context.Background(), host)
+		if got := err == nil; got != want {
+			t.Errorf("CheckHost(%s) error = %v, want allowed %v", host, err, want)
+		}
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	got := ExtractURLs("see https://example.com/a, and (http://example.org/b). again https://example.com/a ftp://nope")
+	want := []string{"https://example.com/a", "http://example.org/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractURLs() = %v, want %v", got, want)
+	}
+	if got := ExtractURLs("no links here"); len(got) != 0 {
+		t.Errorf("ExtractURLs() = %v, want none", got)
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts requests per key in fixed one-minute windows. Each call
+// supplies the limit, so keys on different tiers share one Limiter.
+type Limiter struct {
+	mu      sync.Mutex
+	windows map[string]window
+	now     func() time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func New() *Limiter {
+	return &Limiter{
+		windows: map[string]window{},
+		now:     time.Now,
+	}
+}
+
+// Allow records a request for key and reports whether it is within limit,
+// along with how long until the window resets.
+func (limiter *Limiter) Allow(key string, limit int) (bool, time.Duration) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	current := limiter.windows[key]
+	if now.Sub(current.start) >= time.Minute {
+		current = window{start: now}
+		limiter.prune(now)
+	}
+	current.count++
+	limiter.windows[key] = current
+
+	return current.count <= limit, current.start.Add(time.Minute).Sub(now)
+}
+
+// prune drops expired windows so idle keys don't accumulate.
+func (limiter *Limiter) prune(now time.Time) {
+	for key, current := range limiter.windows {
+		if now.Sub(current.start) >= time.Minute {
+			delete(limiter.windows, key)
+		}
+	}
+}
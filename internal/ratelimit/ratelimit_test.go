@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := New()
+	limiter.now = func() time.Time { return now }
+
+	for i := range 3 {
+		if allowed, _ := limiter.Allow("user", 3); !allowed {
+			t.Fatalf("Allow() request %d rejected within limit", i+1)
+		}
+	}
+	if allowed, _ := limiter.Allow("user", 3); allowed {
+		t.Errorf("Allow() accepted request over limit")
+	}
+	if allowed, _ := limiter.Allow("other", 3); !allowed {
+		t.Errorf("Allow() limited an unrelated key")
+	}
+
+	now = now.Add(time.Minute)
+	if allowed, _ := limiter.Allow("user", 3); !allowed {
+		t.Errorf("Allow() still limited after the window reset")
+	}
+}
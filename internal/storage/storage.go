@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore holds uploaded files under slash-separated keys.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, body io.Reader) error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients fetch the blob.
+	URL(key string) string
+}
+
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && path.Clean(key) == key && !strings.HasPrefix(key, "..")
+}
+
+// LocalStore keeps blobs in a directory, which main serves with a
+// FileServer under BaseURL.
+type LocalStore struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocalStore(root, baseURL string) *LocalStore {
+	return &LocalStore{Root: root, BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (store *LocalStore) Put(ctx context.Context, key, contentType string, body io.Reader) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	target := filepath.Join(store.Root, filepath.FromSlash(key))
+	err := os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial blob.
+	file, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), target)
+}
+
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(filepath.Join(store.Root, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (store *LocalStore) URL(key string) string {
+	return store.BaseURL + "/" + key
+}
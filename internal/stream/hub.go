@@ -0,0 +1,207 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/lib/pq"
+)
+
+const (
+	// Channel is the Postgres NOTIFY channel carrying new event IDs.
+	Channel = "chirp_stream"
+
+	// Retention is how long events stay available for Last-Event-ID resume.
+	Retention = 24 * time.Hour
+
+	// ReplayLimit caps how many events are read per catch-up query.
+	ReplayLimit = 500
+
+	// settleTimeout is how long the hub waits on a missing event ID before
+	// assuming the transaction that took it rolled back. IDs come from a
+	// sequence when the row is inserted, so a lower one can commit after a
+	// higher one.
+	settleTimeout = 10 * time.Second
+
+	subscriberBuffer = 64
+	pollInterval     = 30 * time.Second
+	gapRetryInterval = time.Second
+	pruneInterval    = time.Hour
+)
+
+// Subscriber receives events broadcast by a Hub. Events is closed when the
+// subscriber falls too far behind; clients are expected to reconnect with
+// Last-Event-ID and replay what they missed.
+type Subscriber struct {
+	Events chan database.ChirpStreamEvent
+}
+
+// Hub fans chirp_stream_events rows out to the streams connected to this
+// replica. Rows are written by whichever replica handled the request and
+// announced with NOTIFY, so every replica's hub sees every event. Events are
+// broadcast strictly in ID order, holding back behind a gap until it fills
+// or settleTimeout passes.
+type Hub struct {
+	Queries *database.Queries
+
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+	lastID      int64
+	gapSince    time.Time
+}
+
+func NewHub(queries *database.Queries) *Hub {
+	return &Hub{
+		Queries:     queries,
+		subscribers: map[*Subscriber]struct{}{},
+	}
+}
+
+func (hub *Hub) Subscribe() *Subscriber {
+	subscriber := &Subscriber{Events: make(chan database.ChirpStreamEvent, subscriberBuffer)}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (hub *Hub) Unsubscribe(subscriber *Subscriber) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if _, ok := hub.subscribers[subscriber]; ok {
+		delete(hub.subscribers, subscriber)
+		close(subscriber.Events)
+	}
+}
+
+// Run listens for notifications on dbURL until ctx is cancelled. It also
+// polls periodically so events whose notification was lost while the
+// listener reconnected are still delivered.
+func (hub *Hub) Run(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("Chirp stream listener: %s\n", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(Channel)
+	if err != nil {
+		fmt.Printf("Couldn't listen for chirp stream: %s\n", err)
+		return
+	}
+
+	lastID, err := hub.Queries.LatestChirpStreamEventID(ctx)
+	if err != nil {
+		fmt.Printf("Couldn't read chirp stream: %s\n", err)
+	}
+	hub.mu.Lock()
+	hub.lastID = lastID
+	hub.mu.Unlock()
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+	var gapRetry <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Notify:
+		case <-poll.C:
+		case <-gapRetry:
+		case <-prune.C:
+			err := hub.Queries.PruneChirpStreamEvents(ctx, time.Now().UTC().Add(-Retention))
+			if err != nil {
+				fmt.Printf("Couldn't prune chirp stream: %s\n", err)
+			}
+			continue
+		}
+
+		gapRetry = nil
+		if hub.catchUp(ctx) {
+			gapRetry = time.After(gapRetryInterval)
+		}
+	}
+}
+
+// LastID is the highest event ID the hub has broadcast. Every lower event
+// has been broadcast or given up on, so replaying up to it never gets ahead
+// of a gap the hub is still waiting on.
+func (hub *Hub) LastID() int64 {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return hub.lastID
+}
+
+// catchUp broadcasts the events committed since LastID, reporting whether it
+// stopped at a gap that should be checked again shortly.
+func (hub *Hub) catchUp(ctx context.Context) bool {
+	for {
+		events, err := hub.Queries.ChirpStreamEventsAfter(ctx, database.ChirpStreamEventsAfterParams{
+			AfterID: hub.LastID(),
+			UntilID: math.MaxInt64,
+			Limit:   ReplayLimit,
+		})
+		if err != nil {
+			fmt.Printf("Couldn't read chirp stream: %s\n", err)
+			return false
+		}
+		for _, event := range events {
+			if !hub.advance(event, time.Now()) {
+				return true
+			}
+		}
+		if len(events) < ReplayLimit {
+			return false
+		}
+	}
+}
+
+// advance broadcasts event if it is the next one in ID order, or if the IDs
+// missing before it have been outstanding for settleTimeout.
+func (hub *Hub) advance(event database.ChirpStreamEvent, now time.Time) bool {
+	hub.mu.Lock()
+	if event.ID > hub.lastID+1 {
+		if hub.gapSince.IsZero() {
+			hub.gapSince = now
+		}
+		if now.Sub(hub.gapSince) < settleTimeout {
+			hub.mu.Unlock()
+			return false
+		}
+	}
+	hub.gapSince = time.Time{}
+	hub.mu.Unlock()
+
+	hub.Broadcast(event)
+	return true
+}
+
+// Broadcast hands event to every subscriber, dropping any whose buffer is
+// full rather than blocking the others.
+func (hub *Hub) Broadcast(event database.ChirpStreamEvent) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if event.ID <= hub.lastID {
+		return
+	}
+	hub.lastID = event.ID
+
+	for subscriber := range hub.subscribers {
+		select {
+		case subscriber.Events <- event:
+		default:
+			delete(hub.subscribers, subscriber)
+			close(subscriber.Events)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+)
+
+func TestBroadcast(t *testing.T) {
+	hub := NewHub(nil)
+	subscriber := hub.Subscribe()
+
+	hub.Broadcast(database.ChirpStreamEvent{ID: 1})
+	hub.Broadcast(database.ChirpStreamEvent{ID: 1})
+	hub.Broadcast(database.ChirpStreamEvent{ID: 2})
+
+	for _, wantID := range []int64{1, 2} {
+		event := <-subscriber.Events
+		if event.ID != wantID {
+			t.Errorf("Broadcast() got ID = %v, want %v", event.ID, wantID)
+		}
+	}
+	select {
+	case event := <-subscriber.Events:
+		t.Errorf("Broadcast() delivered duplicate event %v", event.ID)
+	default:
+	}
+}
+
+func TestBroadcastDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(nil)
+	slow := hub.Subscribe()
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		hub.Broadcast(database.ChirpStreamEvent{ID: id})
+	}
+
+	count := 0
+	for range slow.Events {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("slow subscriber received %v events before close, want %v", count, subscriberBuffer)
+	}
+
+	// Unsubscribing after the hub dropped it must not close twice.
+	hub.Unsubscribe(slow)
+}
+
+func TestAdvanceWaitsOnGap(t *testing.T) {
+	hub := NewHub(nil)
+	subscriber := hub.Subscribe()
+	now := time.Now()
+
+	if !hub.advance(database.ChirpStreamEvent{ID: 1}, now) {
+		t.Fatalf("advance() held back the next event")
+	}
+	// ID 2 was taken by a transaction that hasn't committed yet.
+	if hub.advance(database.ChirpStreamEvent{ID: 3}, now) {
+		t.Errorf("advance() broadcast past a fresh gap")
+	}
+	if !hub.advance(database.ChirpStreamEvent{ID: 2}, now.Add(time.Second)) ||
+		!hub.advance(database.ChirpStreamEvent{ID: 3}, now.Add(time.Second)) {
+		t.Errorf("advance() held back events once the gap filled")
+	}
+
+	// ID 4 never commits.
+	if hub.advance(database.ChirpStreamEvent{ID: 5}, now.Add(2*time.Second)) {
+		t.Errorf("advance() broadcast past a fresh gap")
+	}
+	if !hub.advance(database.ChirpStreamEvent{ID: 5}, now.Add(2*time.Second+settleTimeout)) {
+		t.Errorf("advance() still waiting after settleTimeout")
+	}
+
+	for _, wantID := range []int64{1, 2, 3, 5} {
+		if event := <-subscriber.Events; event.ID != wantID {
+			t.Errorf("subscriber got ID = %v, want %v", event.ID, wantID)
+		}
+	}
+	if hub.LastID() != 5 {
+		t.Errorf("LastID() = %v, want 5", hub.LastID())
+	}
+}
@@ -0,0 +1,43 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ConnLimiter caps how many live connections each user holds open on this
+// replica.
+type ConnLimiter struct {
+	mu    sync.Mutex
+	open  map[uuid.UUID]int
+	limit int
+}
+
+func NewConnLimiter(limit int) *ConnLimiter {
+	return &ConnLimiter{
+		open:  map[uuid.UUID]int{},
+		limit: limit,
+	}
+}
+
+// Acquire reserves a connection for userID, reporting false if the user is
+// already at the limit. Every successful Acquire must be paired with Release.
+func (limiter *ConnLimiter) Acquire(userID uuid.UUID) bool {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if limiter.open[userID] >= limiter.limit {
+		return false
+	}
+	limiter.open[userID]++
+	return true
+}
+
+func (limiter *ConnLimiter) Release(userID uuid.UUID) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.open[userID]--
+	if limiter.open[userID] <= 0 {
+		delete(limiter.open, userID)
+	}
+}
@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	TopicTimeline      = "timeline"
+	TopicReplies       = "replies"
+	TopicNotifications = "notifications"
+)
+
+// Topic selects the events a live client has asked for. AuthorID optionally
+// narrows a timeline, ChirpID names the chirp whose replies are wanted and
+// UserID is the subscriber, whose notifications are delivered.
+type Topic struct {
+	Name     string
+	AuthorID uuid.UUID
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (topic Topic) Validate() error {
+	switch topic.Name {
+	case TopicTimeline, TopicNotifications:
+		return nil
+	case TopicReplies:
+		if topic.ChirpID == uuid.Nil {
+			return errors.New("chirp_id is required")
+		}
+		return nil
+	default:
+		return errors.New("unknown topic")
+	}
+}
+
+// Matches reports whether event belongs on topic.
+func (topic Topic) Matches(event database.ChirpStreamEvent) bool {
+	switch topic.Name {
+	case TopicTimeline:
+		if !isChirpEvent(event.Event) {
+			return false
+		}
+		return topic.AuthorID == uuid.Nil || event.UserID == topic.AuthorID
+	case TopicReplies:
+		if !isChirpEvent(event.Event) {
+			return false
+		}
+		reply := struct {
+			ReplyToID uuid.UUID `json:"reply_to_id"`
+		}{}
+		err := json.Unmarshal(event.Payload, &reply)
+		return err == nil && reply.ReplyToID == topic.ChirpID
+	case TopicNotifications:
+		return event.RecipientID.Valid && event.RecipientID.UUID == topic.UserID
+	}
+	return false
+}
+
+func isChirpEvent(event string) bool {
+	return event == "chirp.created" || event == "chirp.deleted" || event == "chirp.restored"
+}
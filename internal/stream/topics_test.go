@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestTopicMatches(t *testing.T) {
+	author := uuid.New()
+	parent := uuid.New()
+	reader := uuid.New()
+	reply := database.ChirpStreamEvent{
+		Event:       "chirp.created",
+		UserID:      author,
+		Payload:     json.RawMessage(`{"reply_to_id":"` + parent.String() + `"}`),
+		RecipientID: uuid.NullUUID{UUID: reader, Valid: true},
+	}
+	plain := database.ChirpStreamEvent{
+		Event:   "chirp.created",
+		UserID:  uuid.New(),
+		Payload: json.RawMessage(`{}`),
+	}
+
+	tests := []struct {
+		name  string
+		topic Topic
+		event database.ChirpStreamEvent
+		want  bool
+	}{
+		{
+			name:  "Timeline without author",
+			topic: Topic{Name: TopicTimeline},
+			event: plain,
+			want:  true,
+		},
+		{
+			name:  "Timeline includes restored chirps",
+			topic: Topic{Name: TopicTimeline},
+			event: database.ChirpStreamEvent{Event: "chirp.restored", UserID: author, Payload: json.RawMessage(`{}`)},
+			want:  true,
+		},
+		{
+			name:  "Timeline filtered by author",
+			topic: Topic{Name: TopicTimeline, AuthorID: author},
+			event: plain,
+			want:  false,
+		},
+		{
+			name:  "Replies to chirp",
+			topic: Topic{Name: TopicReplies, ChirpID: parent},
+			event: reply,
+			want:  true,
+		},
+		{
+			name:  "Replies to other chirp",
+			topic: Topic{Name: TopicReplies, ChirpID: uuid.New()},
+			event: reply,
+			want:  false,
+		},
+		{
+			name:  "Own notifications",
+			topic: Topic{Name: TopicNotifications, UserID: reader},
+			event: reply,
+			want:  true,
+		},
+		{
+			name:  "Someone else's notifications",
+			topic: Topic{Name: TopicNotifications, UserID: author},
+			event: reply,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.topic.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnLimiter(t *testing.T) {
+	limiter := NewConnLimiter(2)
+	userID := uuid.New()
+
+	if !limiter.Acquire(userID) || !limiter.Acquire(userID) {
+		t.Fatalf("Acquire() rejected a connection under the limit")
+	}
+	if limiter.Acquire(userID) {
+		t.Errorf("Acquire() allowed a connection over the limit")
+	}
+	limiter.Release(userID)
+	if !limiter.Acquire(userID) {
+		t.Errorf("Acquire() rejected a connection after Release()")
+	}
+}
@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+const testSecret = "whsec_test"
+
+// fakeDeliveries serves one pending delivery to url from db.
+func fakeDeliveries(db *dbtest.DB, url string) uuid.UUID {
+	deliveryID, subscriptionID := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+
+	db.Handle("ClaimWebhookDeliveries", func([]driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "event", "payload", "status", "attempts",
+				"next_attempt_at", "last_error", "response_status", "delivered_at", "subscription_id"},
+			Values: [][]driver.Value{{
+				deliveryID.String(), now, now, EventChirpCreated, []byte(`{"id":"1"}`), StatusPending, int64(0),
+				now, nil, nil, nil, subscriptionID.String(),
+			}},
+		}, nil
+	})
+	db.Handle("WebhookSubscription", func([]driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "url", "secret", "events", "active", "user_id"},
+			Values: [][]driver.Value{{
+				subscriptionID.String(), now, now, url, testSecret, []byte("{chirp.created}"), true, nil,
+			}},
+		}, nil
+	})
+	db.Handle("MarkWebhookDelivered", func([]driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Affected: 1}, nil
+	})
+	db.Handle("MarkWebhookDeliveryFailed", func([]driver.Value) (dbtest.Rows, error) {
+		return dbtest.Rows{Affected: 1}, nil
+	})
+	return deliveryID
+}
+
+func allowAll(netip.Addr) bool { return true }
+
+func TestDeliverRetriesThenDelivers(t *testing.T) {
+	responses := []int{http.StatusBadGateway, http.StatusNoContent}
+	signatures := []error{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		signatures = append(signatures, auth.VerifySignature(
+			request.Header.Get(SignatureHeader), body, []string{testSecret}, time.Minute, time.Now(),
+		))
+		writer.WriteHeader(responses[0])
+		responses = responses[1:]
+	}))
+	defer server.Close()
+
+	db := dbtest.New()
+	deliveryID := fakeDeliveries(db, server.URL)
+	dispatcher := NewDispatcher(database.New(db), allowAll)
+
+	dispatcher.DeliverDue(context.Background())
+	// lease_seconds, limit
+	if claims := db.Calls("ClaimWebhookDeliveries"); len(claims) != 1 || claims[0].Args[0] != int64(claimLease/time.Second) {
+		t.Errorf("ClaimWebhookDeliveries calls = %v, want one leasing for %v", claims, claimLease)
+	}
+	failed := db.Calls("MarkWebhookDeliveryFailed")
+	if len(failed) != 1 {
+		t.Fatalf("MarkWebhookDeliveryFailed calls = %d, want 1", len(failed))
+	}
+	// id, status, next_attempt_at, last_error, response_status
+	if failed[0].Args[0] != deliveryID.String() || failed[0].Args[1] != StatusPending || failed[0].Args[4] != int64(http.StatusBadGateway) {
+		t.Errorf("MarkWebhookDeliveryFailed args = %v, want a pending retry after %d", failed[0].Args, http.StatusBadGateway)
+	}
+	if next := failed[0].Args[2].(time.Time); next.Before(time.Now().Add(Backoff(1) - time.Minute)) {
+		t.Errorf("MarkWebhookDeliveryFailed next attempt = %v, want about %v from now", next, Backoff(1))
+	}
+
+	dispatcher.DeliverDue(context.Background())
+	delivered := db.Calls("MarkWebhookDelivered")
+	if len(delivered) != 1 {
+		t.Fatalf("MarkWebhookDelivered calls = %d, want 1", len(delivered))
+	}
+	if delivered[0].Args[0] != deliveryID.String() || delivered[0].Args[1] != int64(http.StatusNoContent) {
+		t.Errorf("MarkWebhookDelivered args = %v, want status %d", delivered[0].Args, http.StatusNoContent)
+	}
+
+	if len(signatures) != 2 {
+		t.Fatalf("subscriber received %d requests, want 2", len(signatures))
+	}
+	for i, err := range signatures {
+		if err != nil {
+			t.Errorf("request %d signature error = %v", i, err)
+		}
+	}
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	db := dbtest.New()
+	fakeDeliveries(db, server.URL)
+	NewDispatcher(database.New(db), nil).DeliverDue(context.Background())
+
+	if requests != 0 {
+		t.Errorf("subscriber on loopback received %d requests, want 0", requests)
+	}
+	if len(db.Calls("MarkWebhookDeliveryFailed")) != 1 {
+		t.Errorf("delivery to loopback wasn't recorded as failed")
+	}
+}
+
+func TestDeliverDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	redirector := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirector.Close()
+
+	db := dbtest.New()
+	fakeDeliveries(db, redirector.URL)
+	NewDispatcher(database.New(db), allowAll).DeliverDue(context.Background())
+
+	if followed {
+		t.Errorf("dispatcher followed a redirect")
+	}
+	failed := db.Calls("MarkWebhookDeliveryFailed")
+	if len(failed) != 1 || failed[0].Args[4] != int64(http.StatusTemporaryRedirect) {
+		t.Errorf("MarkWebhookDeliveryFailed calls = %v, want one with status %d", failed, http.StatusTemporaryRedirect)
+	}
+}
@@ -0,0 +1,201 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/preview"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+
+	SignatureHeader = "X-Chirpy-Signature"
+
+	maxAttempts     = 8
+	deliveryTimeout = 10 * time.Second
+	baseBackoff     = 30 * time.Second
+	maxBackoff      = 6 * time.Hour
+
+	// A claimed batch is leased for claimLease, which must outlast delivering
+	// all of it one after another (batchSize * deliveryTimeout) or another
+	// dispatcher can claim and send the tail of the batch again.
+	batchSize  = 20
+	claimLease = 5 * time.Minute
+)
+
+const (
+	EventChirpCreated  = "chirp.created"
+	EventChirpDeleted  = "chirp.deleted"
+	EventChirpRestored = "chirp.restored"
+	EventUserUpgraded  = "user.upgraded"
+)
+
+var KnownEvents = []string{
+	EventChirpCreated,
+	EventChirpDeleted,
+	EventChirpRestored,
+	EventUserUpgraded,
+}
+
+// Envelope is the JSON body POSTed to subscribers.
+type Envelope struct {
+	ID        uuid.UUID       `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Dispatcher delivers queued webhook_deliveries rows. Several dispatchers,
+// in this process or other replicas, can run at once because deliveries are
+// claimed with SKIP LOCKED.
+type Dispatcher struct {
+	Queries      *database.Queries
+	Client       *http.Client
+	PollInterval time.Duration
+}
+
+// NewDispatcher returns a Dispatcher whose client only dials addresses allow
+// accepts, or public ones when allow is nil, and doesn't follow redirects, so
+// a subscriber URL can't be used to reach internal services.
+func NewDispatcher(queries *database.Queries, allow func(netip.Addr) bool) *Dispatcher {
+	return &Dispatcher{
+		Queries: queries,
+		Client: &http.Client{
+			Transport: preview.GuardedTransport(allow, deliveryTimeout),
+			Timeout:   deliveryTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		dispatcher.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is currently due.
+func (dispatcher *Dispatcher) DeliverDue(ctx context.Context) {
+	for {
+		deliveries, err := dispatcher.Queries.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+			LeaseSeconds: int32(claimLease / time.Second),
+			Limit:        batchSize,
+		})
+		if err != nil {
+			fmt.Printf("Couldn't claim webhook deliveries: %s\n", err)
+			return
+		}
+		for _, delivery := range deliveries {
+			dispatcher.deliver(ctx, delivery)
+		}
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func (dispatcher *Dispatcher) deliver(ctx context.Context, delivery database.WebhookDelivery) {
+	subscription, err := dispatcher.Queries.WebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		dispatcher.fail(ctx, delivery, fmt.Errorf("subscription lookup: %w", err), 0)
+		return
+	}
+
+	body, err := json.Marshal(Envelope{
+		ID:        delivery.ID,
+		Event:     delivery.Event,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		dispatcher.fail(ctx, delivery, err, 0)
+		return
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		dispatcher.fail(ctx, delivery, err, 0)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, auth.SignPayload(subscription.Secret, time.Now(), body))
+
+	response, err := dispatcher.Client.Do(request)
+	if err != nil {
+		dispatcher.fail(ctx, delivery, err, 0)
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		dispatcher.fail(ctx, delivery, fmt.Errorf("subscriber returned %d", response.StatusCode), response.StatusCode)
+		return
+	}
+
+	err = dispatcher.Queries.MarkWebhookDelivered(ctx, database.MarkWebhookDeliveredParams{
+		ID:             delivery.ID,
+		ResponseStatus: sql.NullInt32{Int32: int32(response.StatusCode), Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("Couldn't mark webhook delivered: %s\n", err)
+	}
+}
+
+// fail schedules a retry, or moves the delivery to the dead-letter state once
+// it has used up its attempts.
+func (dispatcher *Dispatcher) fail(ctx context.Context, delivery database.WebhookDelivery, cause error, statusCode int) {
+	attempts := int(delivery.Attempts) + 1
+	status := StatusPending
+	if attempts >= maxAttempts {
+		status = StatusDead
+	}
+
+	err := dispatcher.Queries.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         status,
+		NextAttemptAt:  time.Now().UTC().Add(Backoff(attempts)),
+		LastError:      sql.NullString{String: cause.Error(), Valid: true},
+		ResponseStatus: sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0},
+	})
+	if err != nil {
+		fmt.Printf("Couldn't record webhook failure: %s\n", err)
+	}
+}
+
+// Backoff doubles the wait after each failed attempt, capped at maxBackoff.
+func Backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
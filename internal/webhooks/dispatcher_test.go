@@ -0,0 +1,44 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{
+			name:     "First retry",
+			attempts: 1,
+			want:     30 * time.Second,
+		},
+		{
+			name:     "Doubles each attempt",
+			attempts: 4,
+			want:     4 * time.Minute,
+		},
+		{
+			name:     "Capped",
+			attempts: 20,
+			want:     6 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.attempts); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimLeaseOutlastsBatch(t *testing.T) {
+	if batchSize*deliveryTimeout >= claimLease {
+		t.Errorf("claimLease = %v, want more than %v to deliver a full batch", claimLease, batchSize*deliveryTimeout)
+	}
+}
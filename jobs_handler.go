@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at"`
+}
+
+func jobFromDB(dbJob database.Job) Job {
+	return Job{
+		ID:          dbJob.ID,
+		CreatedAt:   dbJob.CreatedAt,
+		UpdatedAt:   dbJob.UpdatedAt,
+		Kind:        dbJob.Kind,
+		Payload:     dbJob.Payload,
+		Status:      dbJob.Status,
+		Attempts:    dbJob.Attempts,
+		MaxAttempts: dbJob.MaxAttempts,
+		RunAt:       dbJob.RunAt,
+		LastError:   dbJob.LastError.String,
+		CompletedAt: timePointer(dbJob.CompletedAt),
+	}
+}
+
+func (config *apiConfig) listJobsHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := request.URL.Query().Get("status")
+	kind := request.URL.Query().Get("kind")
+	dbJobs, err := config.databaseQueries.ListJobs(request.Context(), database.ListJobsParams{
+		Status: sql.NullString{String: status, Valid: status != ""},
+		Kind:   sql.NullString{String: kind, Valid: kind != ""},
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list jobs")
+		return
+	}
+
+	returnJobs := []Job{}
+	for _, dbJob := range dbJobs {
+		returnJobs = append(returnJobs, jobFromDB(dbJob))
+	}
+	respondWithJSON(writer, http.StatusOK, returnJobs)
+}
+
+func (config *apiConfig) singleJobHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(request.PathValue("jobID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	dbJob, err := config.databaseQueries.Job(request.Context(), id)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No job found")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, jobFromDB(dbJob))
+}
+
+// retryJobHandler puts a dead job back in the queue with fresh attempts.
+func (config *apiConfig) retryJobHandler(writer http.ResponseWriter, request *http.Request) {
+	id, err := uuid.Parse(request.PathValue("jobID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	retried, err := config.databaseQueries.RetryJob(request.Context(), id)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't retry job")
+		return
+	}
+	if retried == 0 {
+		respondWithError(writer, http.StatusNotFound, "No dead job found")
+		return
+	}
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/amstein4920/chirpy-http-server/internal/preview"
+	"github.com/google/uuid"
+)
+
+const (
+	jobKindLinkPreviews = "links.preview"
+
+	// linkPreviewTTL is how long a fetched (or failed) preview is reused
+	// before the page is fetched again.
+	linkPreviewTTL = 24 * time.Hour
+)
+
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+type linkPreviewsJob struct {
+	URLs []string `json:"urls"`
+}
+
+// saveChirpLinks replaces the chirp's links and, if it has any, queues their
+// previews to be fetched once the transaction commits.
+func saveChirpLinks(ctx context.Context, queries *database.Queries, chirpID uuid.UUID, body string) error {
+	err := queries.DelChirpLinks(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	urls := preview.ExtractURLs(body)
+	if len(urls) == 0 {
+		return nil
+	}
+	err = queries.AddChirpLinks(ctx, database.AddChirpLinksParams{
+		ChirpID: chirpID,
+		Urls:    urls,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = jobs.Enqueue(ctx, queries, jobKindLinkPreviews, linkPreviewsJob{URLs: urls}, time.Now())
+	return err
+}
+
+// linkPreviewsJob fetches previews not already cached. A page that can't be
+// previewed is cached as a failure so it isn't retried for every chirp.
+func (config *apiConfig) linkPreviewsJob(ctx context.Context, payload json.RawMessage) error {
+	job := linkPreviewsJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range job.URLs {
+		fetchedAt, err := config.databaseQueries.LinkPreviewFetchedAt(ctx, url)
+		if err == nil && time.Since(fetchedAt) < linkPreviewTTL {
+			continue
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		found, fetchErr := config.previews.Fetch(ctx, url)
+		if fetchErr != nil {
+			fmt.Printf("Couldn't preview %s: %s\n", url, fetchErr)
+		}
+		err = config.databaseQueries.SaveLinkPreview(ctx, database.SaveLinkPreviewParams{
+			Url:         url,
+			Ok:          fetchErr == nil && found.Title != "",
+			Title:       found.Title,
+			Description: found.Description,
+			ImageUrl:    found.ImageURL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkPreviewsFor returns the preview of each chirp's first previewable link.
+func (config *apiConfig) linkPreviewsFor(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID]*LinkPreview, error) {
+	previews := map[uuid.UUID]*LinkPreview{}
+	if len(chirpIDs) == 0 {
+		return previews, nil
+	}
+	rows, err := config.databaseQueries.PreviewsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		previews[row.ChirpID] = &LinkPreview{
+			URL:         row.Url,
+			Title:       row.Title,
+			Description: row.Description,
+			ImageURL:    row.ImageUrl,
+		}
+	}
+	return previews, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/stream"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveMaxConnsPerUser  = 5
+	liveMaxSubscriptions = 20
+	liveMaxMessageSize   = 4096
+	liveControlBuffer    = 16
+	liveWriteWait        = 10 * time.Second
+	livePongWait         = 60 * time.Second
+	livePingPeriod       = 50 * time.Second
+)
+
+// Clients authenticate with a Bearer header rather than cookies, so a
+// cross-origin page can't ride on a user's session and any origin may
+// connect.
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(request *http.Request) bool { return true },
+}
+
+type liveClientMessage struct {
+	Type     string    `json:"type"`
+	ID       string    `json:"id"`
+	Topic    string    `json:"topic"`
+	AuthorID uuid.UUID `json:"author_id"`
+	ChirpID  uuid.UUID `json:"chirp_id"`
+}
+
+type liveServerMessage struct {
+	Type          string          `json:"type"`
+	ID            string          `json:"id,omitempty"`
+	Subscriptions []string        `json:"subscriptions,omitempty"`
+	EventID       int64           `json:"event_id,omitempty"`
+	Event         string          `json:"event,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// liveHandler upgrades to a WebSocket on which the client subscribes to
+// timelines, replies to a chirp and its own notifications. Each message
+// carries the IDs of the client's subscriptions it matched.
+func (config *apiConfig) liveHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	if !config.liveConns.Acquire(userId) {
+		respondWithError(writer, http.StatusTooManyRequests, "Too many live connections")
+		return
+	}
+	defer config.liveConns.Release(userId)
+
+	conn, err := liveUpgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	subscriber := config.chirpStream.Subscribe()
+	defer config.chirpStream.Unsubscribe(subscriber)
+
+	var mu sync.Mutex
+	topics := map[string]stream.Topic{}
+
+	control := make(chan liveServerMessage, liveControlBuffer)
+	done := make(chan struct{})
+
+	conn.SetReadLimit(liveMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	go func() {
+		defer close(done)
+		for {
+			message := liveClientMessage{}
+			err := conn.ReadJSON(&message)
+			if err != nil {
+				return
+			}
+
+			reply := liveServerMessage{ID: message.ID}
+			mu.Lock()
+			switch {
+			case message.ID == "":
+				reply.Type, reply.Error = "error", "id is required"
+			case message.Type == "subscribe":
+				topic := stream.Topic{
+					Name:     message.Topic,
+					AuthorID: message.AuthorID,
+					ChirpID:  message.ChirpID,
+					UserID:   userId,
+				}
+				_, exists := topics[message.ID]
+				if err := topic.Validate(); err != nil {
+					reply.Type, reply.Error = "error", err.Error()
+				} else if !exists && len(topics) >= liveMaxSubscriptions {
+					reply.Type, reply.Error = "error", "too many subscriptions"
+				} else {
+					topics[message.ID] = topic
+					reply.Type = "subscribed"
+				}
+			case message.Type == "unsubscribe":
+				delete(topics, message.ID)
+				reply.Type = "unsubscribed"
+			default:
+				reply.Type, reply.Error = "error", "unknown message type"
+			}
+			mu.Unlock()
+
+			// A client that won't read our replies is dropped rather than
+			// allowed to queue them without bound.
+			select {
+			case control <- reply:
+			default:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(livePingPeriod)
+	defer ping.Stop()
+
+	for {
+		var message liveServerMessage
+		select {
+		case <-done:
+			return
+		case <-request.Context().Done():
+			return
+		case message = <-control:
+		case event, ok := <-subscriber.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow"),
+					time.Now().Add(liveWriteWait))
+				return
+			}
+			mu.Lock()
+			matched := []string{}
+			for id, topic := range topics {
+				if topic.Matches(event) {
+					matched = append(matched, id)
+				}
+			}
+			mu.Unlock()
+			if len(matched) == 0 {
+				continue
+			}
+			sort.Strings(matched)
+			message = liveServerMessage{
+				Type:          "event",
+				Subscriptions: matched,
+				EventID:       event.ID,
+				Event:         event.Event,
+				Data:          event.Payload,
+			}
+		case <-ping.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteWait))
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+		err := conn.WriteJSON(message)
+		if err != nil {
+			return
+		}
+	}
+}
@@ -7,8 +7,14 @@ import (
 
 	"github.com/amstein4920/chirpy-http-server/internal/auth"
 	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
 )
 
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
 func (config *apiConfig) loginHandler(writer http.ResponseWriter, request *http.Request) {
 	para, err := decodeEmailPassword(request)
 	if err != nil {
@@ -19,6 +25,10 @@ func (config *apiConfig) loginHandler(writer http.ResponseWriter, request *http.
 
 	dbUser, err := config.databaseQueries.UserPassword(request.Context(), para.Email)
 	if err != nil {
+		config.audit(request.Context(), auditLoginFailed, uuid.Nil, uuid.Nil, map[string]string{
+			"email":  para.Email,
+			"reason": "unknown_email",
+		})
 		fmt.Println("Incorrect email or password")
 		writer.WriteHeader(401)
 		return
@@ -26,12 +36,53 @@ func (config *apiConfig) loginHandler(writer http.ResponseWriter, request *http.
 
 	err = auth.CheckPasswordHash(para.Password, dbUser.HashedPassword.String)
 	if err != nil {
+		config.audit(request.Context(), auditLoginFailed, dbUser.ID, dbUser.ID, map[string]string{
+			"email":  para.Email,
+			"reason": "bad_password",
+		})
 		fmt.Println("Incorrect email or password")
 		writer.WriteHeader(401)
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(dbUser.ID, config.secret, time.Hour)
+	if para.Scopes == nil {
+		para.Scopes = auth.DefaultScopes
+	}
+	if len(para.Scopes) == 0 {
+		respondWithError(writer, 400, "At least one scope is required")
+		return
+	}
+	if !auth.ValidScopes(para.Scopes) {
+		respondWithError(writer, 400, "Unknown scope")
+		return
+	}
+
+	config.completeLogin(writer, request, dbUser, para.Scopes)
+}
+
+// completeLogin hands out an MFA challenge when the user has TOTP enabled and
+// a full session otherwise.
+func (config *apiConfig) completeLogin(writer http.ResponseWriter, request *http.Request, dbUser database.User, scopes []string) {
+	if dbUser.TotpEnabled {
+		mfaToken, err := auth.MakeMFAToken(dbUser.ID, config.secret, 5*time.Minute, scopes)
+		if err != nil {
+			respondWithError(writer, 401, "Couldn't access JWT")
+			return
+		}
+		respondWithJSON(writer, 200, MFAChallenge{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	config.respondWithSession(writer, request, dbUser, scopes)
+}
+
+// respondWithSession issues the access and refresh tokens for a user who has
+// completed every login step.
+func (config *apiConfig) respondWithSession(writer http.ResponseWriter, request *http.Request, dbUser database.User, scopes []string) {
+	accessToken, err := auth.MakeScopedJWT(dbUser.ID, config.secret, time.Hour, scopes)
 	if err != nil {
 		respondWithError(writer, 401, "Couldn't access JWT")
 		return
@@ -43,26 +94,29 @@ func (config *apiConfig) loginHandler(writer http.ResponseWriter, request *http.
 		return
 	}
 
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed.Bool,
+	_, err = config.databaseQueries.CreateRefresh(request.Context(), database.CreateRefreshParams{
+		Token:     refreshToken,
+		UserID:    dbUser.ID,
+		ExpiresAt: time.Now().UTC().Add(60 * 24 * time.Hour),
+		Scopes:    scopes,
+	})
+	if err != nil {
+		respondWithError(writer, 500, "Couldn't store refresh token")
+		return
 	}
+	config.audit(request.Context(), auditLoginSucceeded, dbUser.ID, dbUser.ID, map[string][]string{
+		"scopes": scopes,
+	})
+
+	user := config.userFromDB(request.Context(), dbUser)
 
 	respondWithJSON(writer, 200, Response{
 		User:         user,
 		Token:        accessToken,
 		RefreshToken: refreshToken,
-		IsChirpyRed:  dbUser.IsChirpyRed.Bool,
+		IsChirpyRed:  user.IsChirpyRed,
 		Email:        dbUser.Email,
 	})
-
-	config.databaseQueries.CreateRefresh(request.Context(), database.CreateRefreshParams{
-		Token:  refreshToken,
-		UserID: user.ID,
-	})
 }
 
 func (config *apiConfig) refreshHandler(writer http.ResponseWriter, request *http.Request) {
@@ -76,21 +130,23 @@ func (config *apiConfig) refreshHandler(writer http.ResponseWriter, request *htt
 		return
 	}
 
-	userId, err := config.databaseQueries.CheckRefresh(request.Context(), refreshToken)
+	refresh, err := config.databaseQueries.CheckRefresh(request.Context(), refreshToken)
 	if err != nil {
 		respondWithError(writer, http.StatusUnauthorized, "Couldn't get user for refresh token")
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(
-		userId,
+	accessToken, err := auth.MakeScopedJWT(
+		refresh.UserID,
 		config.secret,
 		time.Hour,
+		refresh.Scopes,
 	)
 	if err != nil {
 		respondWithError(writer, http.StatusUnauthorized, "Couldn't validate token")
 		return
 	}
+	config.audit(request.Context(), auditTokenRefreshed, refresh.UserID, refresh.UserID, nil)
 
 	respondWithJSON(writer, http.StatusOK, response{
 		Token: accessToken,
@@ -108,6 +164,9 @@ func (config *apiConfig) revokeHandler(writer http.ResponseWriter, request *http
 		respondWithError(writer, 410, "Failure")
 		return
 	}
+	if userId, err := config.databaseQueries.RefreshTokenUser(request.Context(), token); err == nil {
+		config.audit(request.Context(), auditTokenRevoked, userId, userId, nil)
+	}
 
 	writer.WriteHeader(http.StatusNoContent)
 }
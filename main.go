@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
 	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/entitlements"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/amstein4920/chirpy-http-server/internal/oidc"
+	"github.com/amstein4920/chirpy-http-server/internal/preview"
+	"github.com/amstein4920/chirpy-http-server/internal/ratelimit"
+	"github.com/amstein4920/chirpy-http-server/internal/storage"
+	"github.com/amstein4920/chirpy-http-server/internal/stream"
+	"github.com/amstein4920/chirpy-http-server/internal/webhooks"
 	"github.com/joho/godotenv"
 
 	_ "github.com/lib/pq"
@@ -18,18 +29,32 @@ import (
 
 type apiConfig struct {
 	fileserverHits  atomic.Int32
+	db              *sql.DB
+	dbURL           string
 	databaseQueries *database.Queries
 	platform        string
 	secret          string
-	polkaKey        string
+	polkaKeys       []string
+	oidcProvider    *oidc.Provider
+	entitlements    entitlements.Config
+	rateLimiter     *ratelimit.Limiter
+	chirpStream     *stream.Hub
+	liveConns       *stream.ConnLimiter
+	blobs           storage.BlobStore
+	previews        *preview.Fetcher
+	trustedProxies  int
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	config := setupEnv()
 
 	serveMux := http.NewServeMux()
 	server := http.Server{
-		Handler: serveMux,
+		Handler: config.withRequestInfo(serveMux),
 		Addr:    ":8080",
 	}
 
@@ -37,26 +62,98 @@ func main() {
 		config.metricsIncrement(
 			http.StripPrefix("/app",
 				http.FileServer(http.Dir(".")))))
+	if local, ok := config.blobs.(*storage.LocalStore); ok {
+		serveMux.Handle("/media/", http.StripPrefix("/media", http.FileServer(mediaFileSystem{http.Dir(local.Root)})))
+	}
 
 	serveMux.HandleFunc("GET /admin/metrics", config.metricsHandler)
-	serveMux.HandleFunc("POST /admin/reset", config.resetHandler)
+	serveMux.HandleFunc("POST /admin/reset", config.requireAdmin(config.requireDisposable(config.resetHandler)))
+	serveMux.HandleFunc("POST /admin/seed", config.requireAdmin(config.requireDisposable(config.seedHandler)))
+	serveMux.HandleFunc("GET /admin/webhooks/events", config.requireAdmin(config.listWebhookEventsHandler))
+	serveMux.HandleFunc("POST /admin/webhooks/events/{eventID}/replay", config.requireAdmin(config.replayWebhookEventHandler))
+	serveMux.HandleFunc("POST /admin/webhooks", config.requireAdmin(config.createGlobalWebhookSubscriptionHandler))
+	serveMux.HandleFunc("GET /admin/webhooks/deliveries", config.requireAdmin(config.adminWebhookDeliveriesHandler))
+	serveMux.HandleFunc("GET /admin/audit", config.requireAdmin(config.listAuditHandler))
+	serveMux.HandleFunc("GET /admin/chirps/{chirpID}", config.requireAdmin(config.adminChirpHandler))
+	serveMux.HandleFunc("GET /admin/jobs", config.requireAdmin(config.listJobsHandler))
+	serveMux.HandleFunc("GET /admin/jobs/{jobID}", config.requireAdmin(config.singleJobHandler))
+	serveMux.HandleFunc("POST /admin/jobs/{jobID}/retry", config.requireAdmin(config.retryJobHandler))
 
 	serveMux.HandleFunc("GET /api/healthz", config.healthHandler)
 	serveMux.HandleFunc("GET /api/chirps", config.allChirpsHandler)
+	serveMux.HandleFunc("GET /api/chirps/stream", config.chirpStreamHandler)
 	serveMux.HandleFunc("GET /api/chirps/{id}", config.singleChirpsHandler)
+	serveMux.HandleFunc("GET /api/hashtags/trending", config.trendingHashtagsHandler)
+	serveMux.HandleFunc("GET /api/hashtags/{tag}/chirps", config.hashtagChirpsHandler)
+	serveMux.HandleFunc("GET /api/live", config.requireScopes(config.liveHandler, auth.ScopeChirpsRead))
 
 	serveMux.HandleFunc("POST /api/polka/webhooks", config.webhooksHandler)
 
 	serveMux.HandleFunc("POST /api/login", config.loginHandler)
+	serveMux.HandleFunc("POST /api/login/mfa", config.loginMFAHandler)
+	serveMux.HandleFunc("GET /api/oidc/login", config.oidcLoginHandler)
+	serveMux.HandleFunc("GET /api/oidc/callback", config.oidcCallbackHandler)
 	serveMux.HandleFunc("POST /api/refresh", config.refreshHandler)
 	serveMux.HandleFunc("POST /api/revoke", config.revokeHandler)
 
 	serveMux.HandleFunc("POST /api/users", config.usersHandler)
-	serveMux.HandleFunc("POST /api/chirps", config.chirpsHandler)
+	serveMux.HandleFunc("POST /api/users/mfa/totp", config.requireScopes(config.totpEnrollHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("POST /api/users/mfa/totp/verify", config.requireScopes(config.totpVerifyHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("POST /api/chirps", config.requireScopes(config.rateLimit(config.chirpsHandler), auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}", config.requireScopes(config.rateLimit(config.editChirpHandler), auth.ScopeChirpsWrite))
+
+	serveMux.HandleFunc("PUT /api/users", config.requireScopes(config.usersUpdateHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/users/subscription", config.requireScopes(config.subscriptionHandler))
+	serveMux.HandleFunc("PUT /api/users/profile", config.requireScopes(config.updateProfileHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/users/me", config.requireScopes(config.deleteAccountHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("POST /api/users/me/restore", config.requireScopes(config.restoreAccountHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/users/me/export", config.requireScopes(config.exportHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/users/{idOrHandle}", config.profileHandler)
+	serveMux.HandleFunc("GET /api/users/{idOrHandle}/followers", config.followersHandler)
+	serveMux.HandleFunc("GET /api/users/{idOrHandle}/following", config.followingHandler)
+	serveMux.HandleFunc("PUT /api/users/{idOrHandle}/follow", config.requireScopes(config.followHandler, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/users/{idOrHandle}/follow", config.requireScopes(config.unfollowHandler, auth.ScopeUsersWrite))
+
+	serveMux.HandleFunc("GET /api/notifications", config.requireScopes(config.listNotificationsHandler, auth.ScopeChirpsRead))
+	serveMux.HandleFunc("POST /api/notifications/read", config.requireScopes(config.readNotificationsHandler, auth.ScopeChirpsRead))
+
+	serveMux.HandleFunc("POST /api/keys", config.requireScopes(config.createAPIKeyHandler, auth.ScopeKeysWrite))
+	serveMux.HandleFunc("GET /api/keys", config.requireScopes(config.listAPIKeysHandler, auth.ScopeKeysWrite))
+	serveMux.HandleFunc("DELETE /api/keys/{keyID}", config.requireScopes(config.revokeAPIKeyHandler, auth.ScopeKeysWrite))
 
-	serveMux.HandleFunc("PUT /api/users", config.usersUpdateHandler)
+	serveMux.HandleFunc("POST /api/webhooks", config.requireScopes(config.createWebhookSubscriptionHandler, auth.ScopeWebhooksWrite))
+	serveMux.HandleFunc("GET /api/webhooks", config.requireScopes(config.listWebhookSubscriptionsHandler, auth.ScopeWebhooksWrite))
+	serveMux.HandleFunc("DELETE /api/webhooks/{webhookID}", config.requireScopes(config.deleteWebhookSubscriptionHandler, auth.ScopeWebhooksWrite))
+	serveMux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", config.requireScopes(config.listWebhookDeliveriesHandler, auth.ScopeWebhooksWrite))
 
-	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", config.deleteChirpHandler)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", config.requireScopes(config.deleteChirpHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/restore", config.requireScopes(config.restoreChirpHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", config.requireScopes(config.rateLimit(config.rechirpHandler), auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/reactions", config.reactionsHandler)
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}/reactions/mine", config.requireScopes(config.reactHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/reactions/mine", config.requireScopes(config.unreactHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/chirps/scheduled", config.requireScopes(config.listScheduledChirpsHandler, auth.ScopeChirpsRead))
+	serveMux.HandleFunc("DELETE /api/chirps/scheduled/{scheduledID}", config.requireScopes(config.cancelScheduledChirpHandler, auth.ScopeChirpsWrite))
+
+	serveMux.HandleFunc("GET /api/bookmarks", config.requireScopes(config.listBookmarksHandler, auth.ScopeChirpsRead))
+	serveMux.HandleFunc("PUT /api/bookmarks/{chirpID}", config.requireScopes(config.addBookmarkHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/bookmarks/{chirpID}", config.requireScopes(config.deleteBookmarkHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("POST /api/collections", config.requireScopes(config.createCollectionHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/collections", config.requireScopes(config.listCollectionsHandler, auth.ScopeChirpsRead))
+	serveMux.HandleFunc("PUT /api/collections/{collectionID}", config.requireScopes(config.renameCollectionHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/collections/{collectionID}", config.requireScopes(config.deleteCollectionHandler, auth.ScopeChirpsWrite))
+
+	serveMux.HandleFunc("POST /api/drafts", config.requireScopes(config.createDraftHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/drafts", config.requireScopes(config.listDraftsHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("PUT /api/drafts/{draftID}", config.requireScopes(config.updateDraftHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/drafts/{draftID}", config.requireScopes(config.deleteDraftHandler, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("POST /api/drafts/{draftID}/publish", config.requireScopes(config.rateLimit(config.publishDraftHandler), auth.ScopeChirpsWrite))
+
+	runner := jobs.NewRunner(config.databaseQueries, 4)
+	config.registerJobs(runner)
+	go runner.Run(context.Background())
+	go webhooks.NewDispatcher(config.databaseQueries, nil).Run(context.Background())
+	go config.chirpStream.Run(context.Background(), config.dbURL)
 
 	server.ListenAndServe()
 }
@@ -108,7 +205,15 @@ func respondWithJSON(writer http.ResponseWriter, code int, payload interface{})
 func setupEnv() apiConfig {
 	godotenv.Load()
 	secret := os.Getenv("SECRET")
-	polkaKey := os.Getenv("POLKA_KEY")
+	// POLKA_KEYS lists every secret currently accepted for webhook
+	// signatures, comma separated, so a new one can be rolled out before the
+	// old one is retired.
+	polkaKeys := []string{}
+	for _, key := range strings.Split(os.Getenv("POLKA_KEYS")+","+os.Getenv("POLKA_KEY"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			polkaKeys = append(polkaKeys, key)
+		}
+	}
 	platform := os.Getenv("PLATFORM")
 	dbURL := os.Getenv("DB_URL")
 	db, err := sql.Open("postgres", dbURL)
@@ -117,10 +222,48 @@ func setupEnv() apiConfig {
 		os.Exit(1)
 	}
 	dbQueries := database.New(db)
+
+	var oidcProvider *oidc.Provider
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		oidcProvider = oidc.NewProvider(
+			issuer,
+			os.Getenv("OIDC_CLIENT_ID"),
+			os.Getenv("OIDC_CLIENT_SECRET"),
+			os.Getenv("OIDC_REDIRECT_URL"),
+		)
+	}
+
+	allowed, err := entitlements.Load(os.Getenv("ENTITLEMENTS_FILE"))
+	if err != nil {
+		fmt.Printf("Failed to load entitlements: %s\n", err)
+		os.Exit(1)
+	}
+
+	// TRUSTED_PROXIES is how many reverse proxies in front of the server
+	// append to X-Forwarded-For; zero ignores the header.
+	trustedProxies := 0
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		trustedProxies, err = strconv.Atoi(value)
+		if err != nil || trustedProxies < 0 {
+			fmt.Println("TRUSTED_PROXIES must be a non-negative number")
+			os.Exit(1)
+		}
+	}
+
 	return apiConfig{
+		db:              db,
+		dbURL:           dbURL,
 		databaseQueries: dbQueries,
 		platform:        platform,
 		secret:          secret,
-		polkaKey:        polkaKey,
+		polkaKeys:       polkaKeys,
+		oidcProvider:    oidcProvider,
+		entitlements:    allowed,
+		rateLimiter:     ratelimit.New(),
+		chirpStream:     stream.NewHub(dbQueries),
+		liveConns:       stream.NewConnLimiter(liveMaxConnsPerUser),
+		blobs:           blobStoreFromEnv(),
+		previews:        preview.NewFetcher(nil),
+		trustedProxies:  trustedProxies,
 	}
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/amstein4920/chirpy-http-server/internal/media"
+	"github.com/amstein4920/chirpy-http-server/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpMedia = 4
+
+	jobKindDeleteMedia = "media.delete"
+)
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+}
+
+// chirpUpload is a POST /api/chirps request, sent either as JSON or as
+// multipart/form-data with "body", "reply_to_id" and "publish_at" fields and
+// up to maxChirpMedia image files under "media".
+type chirpUpload struct {
+	Body      string        `json:"body"`
+	ReplyToID *uuid.UUID    `json:"reply_to_id"`
+	PublishAt *time.Time    `json:"publish_at"`
+	Images    []media.Image `json:"-"`
+}
+
+var (
+	errTooManyMedia     = fmt.Errorf("A chirp can have at most %d attachments", maxChirpMedia)
+	errInvalidReplyTo   = errors.New("Invalid reply_to_id")
+	errInvalidPublishAt = errors.New("Invalid publish_at")
+)
+
+func readChirpUpload(writer http.ResponseWriter, request *http.Request) (chirpUpload, error) {
+	upload := chirpUpload{}
+	contentType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if contentType != "multipart/form-data" {
+		err := json.NewDecoder(request.Body).Decode(&upload)
+		return upload, err
+	}
+
+	request.Body = http.MaxBytesReader(writer, request.Body, maxChirpMedia*media.MaxBytes+1<<20)
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return upload, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return upload, nil
+		}
+		if err != nil {
+			return upload, err
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, media.MaxBytes+1))
+		if err != nil {
+			return upload, err
+		}
+		switch part.FormName() {
+		case "body":
+			upload.Body = string(data)
+		case "reply_to_id":
+			id, err := uuid.Parse(string(data))
+			if err != nil {
+				return upload, errInvalidReplyTo
+			}
+			upload.ReplyToID = &id
+		case "publish_at":
+			at, err := time.Parse(time.RFC3339, string(data))
+			if err != nil {
+				return upload, errInvalidPublishAt
+			}
+			upload.PublishAt = &at
+		case "media":
+			if len(upload.Images) == maxChirpMedia {
+				return upload, errTooManyMedia
+			}
+			image, err := media.Process(data)
+			if err != nil {
+				return upload, err
+			}
+			upload.Images = append(upload.Images, image)
+		}
+	}
+}
+
+// storeMedia uploads the images and their thumbnails, returning the rows to
+// insert once the chirp exists. If the chirp isn't created, pass the rows to
+// deleteStoredMedia.
+func (config *apiConfig) storeMedia(ctx context.Context, images []media.Image) ([]database.CreateChirpMediaParams, error) {
+	stored := []database.CreateChirpMediaParams{}
+	for i, image := range images {
+		id := uuid.New()
+		row := database.CreateChirpMediaParams{
+			ID:          id,
+			Position:    int32(i),
+			ContentType: image.ContentType,
+			Width:       int32(image.Width),
+			Height:      int32(image.Height),
+			BlobKey:     "media/" + id.String() + media.Extension(image.ContentType),
+			ThumbKey:    "media/" + id.String() + "_thumb" + media.Extension(image.ThumbContentType),
+		}
+
+		err := config.blobs.Put(ctx, row.BlobKey, image.ContentType, bytes.NewReader(image.Data))
+		if err == nil {
+			err = config.blobs.Put(ctx, row.ThumbKey, image.ThumbContentType, bytes.NewReader(image.Thumb))
+		}
+		stored = append(stored, row)
+		if err != nil {
+			config.deleteStoredMedia(ctx, stored)
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+func (config *apiConfig) deleteStoredMedia(ctx context.Context, stored []database.CreateChirpMediaParams) {
+	for _, row := range stored {
+		for _, key := range []string{row.BlobKey, row.ThumbKey} {
+			err := config.blobs.Delete(ctx, key)
+			if err != nil {
+				fmt.Printf("Couldn't delete %s: %s\n", key, err)
+			}
+		}
+	}
+}
+
+// mediaFileSystem serves stored blobs but not directories, so the upload
+// directory can't be listed.
+type mediaFileSystem struct {
+	http.FileSystem
+}
+
+func (fileSystem mediaFileSystem) Open(name string) (http.File, error) {
+	file, err := fileSystem.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
+
+func (config *apiConfig) mediaFromDB(row database.ChirpMedium) Media {
+	return Media{
+		ID:           row.ID,
+		URL:          config.blobs.URL(row.BlobKey),
+		ThumbnailURL: config.blobs.URL(row.ThumbKey),
+		ContentType:  row.ContentType,
+		Width:        row.Width,
+		Height:       row.Height,
+	}
+}
+
+type deleteMediaJob struct {
+	Keys []string `json:"keys"`
+}
+
+// enqueueMediaDeletion removes a chirp's blobs once the transaction deleting
+// it commits.
+func enqueueMediaDeletion(ctx context.Context, queries *database.Queries, chirpIDs []uuid.UUID) error {
+	rows, err := queries.MediaForChirps(ctx, chirpIDs)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	job := deleteMediaJob{}
+	for _, row := range rows {
+		job.Keys = append(job.Keys, row.BlobKey, row.ThumbKey)
+	}
+	_, err = jobs.Enqueue(ctx, queries, jobKindDeleteMedia, job, time.Now())
+	return err
+}
+
+func (config *apiConfig) deleteMediaJob(ctx context.Context, payload json.RawMessage) error {
+	job := deleteMediaJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+	for _, key := range job.Keys {
+		err := config.blobs.Delete(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobStoreFromEnv picks the media store: S3-compatible when S3_BUCKET is
+// set, otherwise MEDIA_DIR on local disk served under /media/.
+func blobStoreFromEnv() storage.BlobStore {
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		return storage.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			bucket,
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_ACCESS_KEY"),
+			os.Getenv("S3_SECRET_KEY"),
+			os.Getenv("S3_PUBLIC_URL"),
+		)
+	}
+	root := os.Getenv("MEDIA_DIR")
+	if root == "" {
+		root = "media"
+	}
+	return storage.NewLocalStore(root, "/media")
+}
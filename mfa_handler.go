@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount = 10
+
+	// maxMFAFailures wrong codes in a row lock a user's second factor for
+	// mfaLockout, however many MFA tokens the attempts were spread across.
+	maxMFAFailures = 5
+	mfaLockout     = 15 * time.Minute
+)
+
+func (config *apiConfig) totpEnrollHandler(writer http.ResponseWriter, request *http.Request) {
+	type response struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+	if dbUser.TotpEnabled {
+		respondWithError(writer, http.StatusConflict, "TOTP already enabled")
+		return
+	}
+
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create TOTP secret")
+		return
+	}
+
+	err = config.databaseQueries.SetTOTPSecret(request.Context(), database.SetTOTPSecretParams{
+		ID: userId,
+		TotpSecret: sql.NullString{
+			String: secret,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't store TOTP secret")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, response{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI("Chirpy", dbUser.Email, secret),
+	})
+}
+
+func (config *apiConfig) totpVerifyHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+	if !dbUser.TotpSecret.Valid {
+		respondWithError(writer, http.StatusBadRequest, "TOTP enrolment not started")
+		return
+	}
+	if dbUser.TotpEnabled {
+		respondWithError(writer, http.StatusConflict, "TOTP already enabled")
+		return
+	}
+
+	step, err := auth.MatchTOTP(params.Code, dbUser.TotpSecret.String, time.Now())
+	if err != nil {
+		respondWithError(writer, http.StatusUnauthorized, "Invalid code")
+		return
+	}
+
+	recoveryCodes, err := auth.MakeRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create recovery codes")
+		return
+	}
+
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		err := queries.DelRecoveryCodes(request.Context(), userId)
+		if err != nil {
+			return err
+		}
+		for _, code := range recoveryCodes {
+			err = queries.CreateRecoveryCode(request.Context(), database.CreateRecoveryCodeParams{
+				CodeHash: auth.HashRecoveryCode(code),
+				UserID:   userId,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		// The enrolment code can't then be replayed to complete a login.
+		_, err = queries.UseTOTPStep(request.Context(), database.UseTOTPStepParams{
+			UserID:       userId,
+			LastTotpStep: step,
+		})
+		if err != nil {
+			return err
+		}
+		return queries.EnableTOTP(request.Context(), userId)
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't enable TOTP")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, response{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// loginMFAHandler completes a login started by loginHandler for users with
+// TOTP enabled, accepting either a current code or an unused recovery code.
+// Each TOTP time step is accepted once, and repeated failures lock the
+// second factor for a while.
+func (config *apiConfig) loginMFAHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	userId, scopes, err := auth.ValidateMFAToken(params.MFAToken, config.secret)
+	if err != nil {
+		respondWithError(writer, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil || !dbUser.TotpEnabled {
+		respondWithError(writer, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	attempts, err := config.databaseQueries.MFAAttempts(request.Context(), userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't check MFA attempts")
+		return
+	}
+	if attempts.LockedUntil.Valid && attempts.LockedUntil.Time.After(time.Now().UTC()) {
+		respondWithError(writer, http.StatusTooManyRequests, "Too many failed attempts, try again later")
+		return
+	}
+
+	if params.RecoveryCode != "" {
+		used, err := config.databaseQueries.UseRecoveryCode(request.Context(), database.UseRecoveryCodeParams{
+			UserID:   userId,
+			CodeHash: auth.HashRecoveryCode(params.RecoveryCode),
+		})
+		if err != nil || used == 0 {
+			config.mfaFailed(request.Context(), userId, "bad_recovery_code")
+			respondWithError(writer, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+	} else {
+		step, err := auth.MatchTOTP(params.Code, dbUser.TotpSecret.String, time.Now())
+		if err != nil {
+			config.mfaFailed(request.Context(), userId, "bad_totp_code")
+			respondWithError(writer, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+		used, err := config.databaseQueries.UseTOTPStep(request.Context(), database.UseTOTPStepParams{
+			UserID:       userId,
+			LastTotpStep: step,
+		})
+		if err != nil {
+			respondWithError(writer, http.StatusInternalServerError, "Couldn't check code")
+			return
+		}
+		if used == 0 {
+			config.mfaFailed(request.Context(), userId, "reused_totp_code")
+			respondWithError(writer, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+	}
+
+	err = config.databaseQueries.ResetMFAFailures(request.Context(), userId)
+	if err != nil {
+		fmt.Printf("Couldn't reset MFA failures for %s: %s\n", userId, err)
+	}
+
+	config.respondWithSession(writer, request, dbUser, scopes)
+}
+
+// mfaFailed audits a rejected second factor and counts it towards the
+// user's lockout.
+func (config *apiConfig) mfaFailed(ctx context.Context, userId uuid.UUID, reason string) {
+	config.audit(ctx, auditLoginFailed, userId, userId, map[string]string{
+		"reason": reason,
+	})
+
+	failures, err := config.databaseQueries.RecordMFAFailure(ctx, userId)
+	if err != nil {
+		fmt.Printf("Couldn't record MFA failure for %s: %s\n", userId, err)
+		return
+	}
+	if failures < maxMFAFailures {
+		return
+	}
+	err = config.databaseQueries.LockMFA(ctx, database.LockMFAParams{
+		UserID:      userId,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(mfaLockout), Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("Couldn't lock MFA for %s: %s\n", userId, err)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationReply    = "reply"
+	notificationMention  = "mention"
+	notificationRechirp  = "rechirp"
+	notificationQuote    = "quote"
+	notificationFollow   = "follow"
+	notificationReaction = "reaction"
+)
+
+const (
+	jobKindChirpNotifications = "notifications.chirp"
+
+	eventNotificationCreated = "notification.created"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func notificationFromDB(dbNotification database.Notification) Notification {
+	notification := Notification{
+		ID:        dbNotification.ID,
+		CreatedAt: dbNotification.CreatedAt,
+		Kind:      dbNotification.Kind,
+		ActorID:   dbNotification.ActorID,
+		ReadAt:    timePointer(dbNotification.ReadAt),
+	}
+	if dbNotification.ChirpID.Valid {
+		notification.ChirpID = &dbNotification.ChirpID.UUID
+	}
+	return notification
+}
+
+type notificationsJob struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+}
+
+// enqueueChirpNotifications defers working out who a new chirp notifies to
+// the job runner, keeping the fan-out off the request path.
+func enqueueChirpNotifications(ctx context.Context, queries *database.Queries, chirpID uuid.UUID) error {
+	_, err := jobs.Enqueue(ctx, queries, jobKindChirpNotifications, notificationsJob{
+		ChirpID: chirpID,
+	}, time.Now())
+	return err
+}
+
+// chirpNotificationsJob notifies the author of the chirp being replied to or
+// reposted, and everyone mentioned. Each user gets one notification, with a
+// reply taking precedence over a repost and a repost over a mention.
+func (config *apiConfig) chirpNotificationsJob(ctx context.Context, payload json.RawMessage) error {
+	job := notificationsJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	chirp, err := config.databaseQueries.SingleChirp(ctx, job.ChirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted before we got to it; nothing to announce.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	mentioned, err := config.databaseQueries.ChirpMentions(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+
+	return config.withTx(ctx, func(queries *database.Queries) error {
+		notified := map[uuid.UUID]bool{}
+		if chirp.ReplyToID.Valid {
+			parent, err := queries.SingleChirp(ctx, chirp.ReplyToID.UUID)
+			if err == nil {
+				notified[parent.UserID] = true
+				err = notify(ctx, queries, parent.UserID, chirp.UserID, notificationReply, chirp.ID)
+			}
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		if chirp.RepostOfID.Valid {
+			original, err := queries.SingleChirp(ctx, chirp.RepostOfID.UUID)
+			if err == nil && !notified[original.UserID] {
+				kind := notificationQuote
+				if isRechirp(chirp) {
+					kind = notificationRechirp
+				}
+				notified[original.UserID] = true
+				err = notify(ctx, queries, original.UserID, chirp.UserID, kind, chirp.ID)
+			}
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		for _, userID := range mentioned {
+			if notified[userID] {
+				continue
+			}
+			err := notify(ctx, queries, userID, chirp.UserID, notificationMention, chirp.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// notify stores a notification for recipient and pushes it to their live
+// connections. Users aren't notified about their own actions, nor twice about
+// the same one, so a retried job doesn't repeat itself.
+func notify(ctx context.Context, queries *database.Queries, recipient, actor uuid.UUID, kind string, chirpID uuid.UUID) error {
+	if recipient == actor {
+		return nil
+	}
+
+	dbNotification, err := queries.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  recipient,
+		ActorID: actor,
+		Kind:    kind,
+		ChirpID: uuid.NullUUID{UUID: chirpID, Valid: chirpID != uuid.Nil},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Already notified.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return recordStreamEvent(ctx, queries, eventNotificationCreated, chirpID, actor,
+		uuid.NullUUID{UUID: recipient, Valid: true}, notificationFromDB(dbNotification))
+}
+
+func (config *apiConfig) listNotificationsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbNotifications, err := config.databaseQueries.ListNotifications(request.Context(), database.ListNotificationsParams{
+		UserID:     userId,
+		UnreadOnly: request.URL.Query().Get("unread") == "true",
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list notifications")
+		return
+	}
+
+	returnNotifications := []Notification{}
+	for _, dbNotification := range dbNotifications {
+		returnNotifications = append(returnNotifications, notificationFromDB(dbNotification))
+	}
+	respondWithJSON(writer, http.StatusOK, returnNotifications)
+}
+
+// readNotificationsHandler marks the listed notifications read, or all of
+// them when no IDs are given.
+func (config *apiConfig) readNotificationsHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	type response struct {
+		Unread int64 `json:"unread"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if len(params.IDs) == 0 {
+		_, err = config.databaseQueries.MarkAllNotificationsRead(request.Context(), userId)
+	} else {
+		_, err = config.databaseQueries.MarkNotificationsRead(request.Context(), database.MarkNotificationsReadParams{
+			UserID: userId,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't update notifications")
+		return
+	}
+
+	unread, err := config.databaseQueries.CountUnreadNotifications(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't count notifications")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, response{Unread: unread})
+}
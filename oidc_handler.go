@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/oidc"
+	"github.com/google/uuid"
+)
+
+const oidcProviderName = "oidc"
+
+func (config *apiConfig) oidcLoginHandler(writer http.ResponseWriter, request *http.Request) {
+	if config.oidcProvider == nil {
+		respondWithError(writer, http.StatusNotFound, "OIDC login not configured")
+		return
+	}
+
+	state, err := oidc.MakeVerifier()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't start login")
+		return
+	}
+	nonce, err := oidc.MakeVerifier()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't start login")
+		return
+	}
+	verifier, err := oidc.MakeVerifier()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't start login")
+		return
+	}
+
+	err = config.databaseQueries.CreateOIDCState(request.Context(), database.CreateOIDCStateParams{
+		State:        state,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().UTC().Add(10 * time.Minute),
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't start login")
+		return
+	}
+
+	authURL, err := config.oidcProvider.AuthCodeURL(request.Context(), state, nonce, verifier)
+	if err != nil {
+		respondWithError(writer, http.StatusBadGateway, "Identity provider unavailable")
+		return
+	}
+
+	http.Redirect(writer, request, authURL, http.StatusFound)
+}
+
+// oidcCallbackHandler finishes the authorization-code flow and logs in the
+// linked user, linking by verified email or creating a password-less account
+// the first time an identity is seen.
+func (config *apiConfig) oidcCallbackHandler(writer http.ResponseWriter, request *http.Request) {
+	if config.oidcProvider == nil {
+		respondWithError(writer, http.StatusNotFound, "OIDC login not configured")
+		return
+	}
+
+	query := request.URL.Query()
+	if query.Get("error") != "" {
+		respondWithError(writer, http.StatusUnauthorized, "Identity provider denied login")
+		return
+	}
+
+	loginState, err := config.databaseQueries.ConsumeOIDCState(request.Context(), query.Get("state"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid or expired state")
+		return
+	}
+
+	claims, err := config.oidcProvider.Exchange(request.Context(), query.Get("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		respondWithError(writer, http.StatusUnauthorized, "Couldn't verify identity")
+		return
+	}
+
+	userId, err := config.databaseQueries.IdentityUserID(request.Context(), database.IdentityUserIDParams{
+		Provider: oidcProviderName,
+		Subject:  claims.Subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		userId, err = config.linkIdentity(request, claims)
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't link identity")
+		return
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load user")
+		return
+	}
+
+	config.completeLogin(writer, request, dbUser, auth.DefaultScopes)
+}
+
+func (config *apiConfig) linkIdentity(request *http.Request, claims oidc.Claims) (uuid.UUID, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return uuid.Nil, errors.New("identity has no verified email")
+	}
+
+	dbUser, err := config.databaseQueries.UserPassword(request.Context(), claims.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		dbUser, err = config.databaseQueries.CreateUser(request.Context(), database.CreateUserParams{
+			Email: claims.Email,
+		})
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = config.databaseQueries.CreateUserIdentity(request.Context(), database.CreateUserIdentityParams{
+		Provider: oidcProviderName,
+		Subject:  claims.Subject,
+		Email: sql.NullString{
+			String: claims.Email,
+			Valid:  true,
+		},
+		UserID: dbUser.ID,
+	})
+	return dbUser.ID, err
+}
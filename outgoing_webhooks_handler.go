@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/preview"
+	"github.com/amstein4920/chirpy-http-server/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+type WebhookSubscription struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	UserID    *uuid.UUID `json:"user_id"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus *int32          `json:"response_status"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+}
+
+func webhookSubscriptionFromDB(dbSubscription database.WebhookSubscription) WebhookSubscription {
+	subscription := WebhookSubscription{
+		ID:        dbSubscription.ID,
+		CreatedAt: dbSubscription.CreatedAt,
+		URL:       dbSubscription.Url,
+		Events:    dbSubscription.Events,
+		Active:    dbSubscription.Active,
+	}
+	if dbSubscription.UserID.Valid {
+		subscription.UserID = &dbSubscription.UserID.UUID
+	}
+	return subscription
+}
+
+func webhookDeliveriesFromDB(dbDeliveries []database.WebhookDelivery) []WebhookDelivery {
+	deliveries := []WebhookDelivery{}
+	for _, dbDelivery := range dbDeliveries {
+		delivery := WebhookDelivery{
+			ID:             dbDelivery.ID,
+			CreatedAt:      dbDelivery.CreatedAt,
+			Event:          dbDelivery.Event,
+			Payload:        dbDelivery.Payload,
+			Status:         dbDelivery.Status,
+			Attempts:       dbDelivery.Attempts,
+			NextAttemptAt:  dbDelivery.NextAttemptAt,
+			LastError:      dbDelivery.LastError.String,
+			DeliveredAt:    timePointer(dbDelivery.DeliveredAt),
+			SubscriptionID: dbDelivery.SubscriptionID,
+		}
+		if dbDelivery.ResponseStatus.Valid {
+			delivery.ResponseStatus = &dbDelivery.ResponseStatus.Int32
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+func (config *apiConfig) createWebhookSubscriptionHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+	config.createWebhookSubscription(writer, request, uuid.NullUUID{UUID: userId, Valid: true})
+}
+
+// createGlobalWebhookSubscriptionHandler registers an admin subscription that
+// receives events for every user.
+func (config *apiConfig) createGlobalWebhookSubscriptionHandler(writer http.ResponseWriter, request *http.Request) {
+	config.createWebhookSubscription(writer, request, uuid.NullUUID{})
+}
+
+func (config *apiConfig) createWebhookSubscription(writer http.ResponseWriter, request *http.Request, owner uuid.NullUUID) {
+	type parameters struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	type response struct {
+		WebhookSubscription
+		Secret string `json:"secret"`
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	target, err := url.Parse(params.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		respondWithError(writer, http.StatusBadRequest, "Invalid URL")
+		return
+	}
+	err = preview.CheckHost(request.Context(), target.Hostname())
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "URL must resolve to a public address")
+		return
+	}
+	if len(params.Events) == 0 {
+		respondWithError(writer, http.StatusBadRequest, "Events are required")
+		return
+	}
+	for _, event := range params.Events {
+		if !slices.Contains(webhooks.KnownEvents, event) {
+			respondWithError(writer, http.StatusBadRequest, "Unknown event: "+event)
+			return
+		}
+	}
+
+	// Subscribers verify deliveries with this secret, so it's returned once
+	// here and never again.
+	secret, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create secret")
+		return
+	}
+
+	dbSubscription, err := config.databaseQueries.CreateWebhookSubscription(request.Context(), database.CreateWebhookSubscriptionParams{
+		Url:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+		UserID: owner,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't create webhook")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, response{
+		WebhookSubscription: webhookSubscriptionFromDB(dbSubscription),
+		Secret:              secret,
+	})
+}
+
+func (config *apiConfig) listWebhookSubscriptionsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbSubscriptions, err := config.databaseQueries.ListWebhookSubscriptions(request.Context(), uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list webhooks")
+		return
+	}
+
+	subscriptions := []WebhookSubscription{}
+	for _, dbSubscription := range dbSubscriptions {
+		subscriptions = append(subscriptions, webhookSubscriptionFromDB(dbSubscription))
+	}
+	respondWithJSON(writer, http.StatusOK, subscriptions)
+}
+
+func (config *apiConfig) deleteWebhookSubscriptionHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	id, err := uuid.Parse(request.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	deleted, err := config.databaseQueries.DelWebhookSubscription(request.Context(), database.DelWebhookSubscriptionParams{
+		ID:     id,
+		UserID: uuid.NullUUID{UUID: userId, Valid: true},
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete webhook")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, http.StatusNotFound, "No webhook found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) listWebhookDeliveriesHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	id, err := uuid.Parse(request.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbSubscription, err := config.databaseQueries.WebhookSubscription(request.Context(), id)
+	if err != nil || dbSubscription.UserID.UUID != userId {
+		respondWithError(writer, http.StatusNotFound, "No webhook found")
+		return
+	}
+
+	dbDeliveries, err := config.databaseQueries.ListWebhookDeliveries(request.Context(), database.ListWebhookDeliveriesParams{
+		SubscriptionID: id,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list deliveries")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, webhookDeliveriesFromDB(dbDeliveries))
+}
+
+func (config *apiConfig) adminWebhookDeliveriesHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := request.URL.Query().Get("status")
+	dbDeliveries, err := config.databaseQueries.ListWebhookDeliveriesByStatus(request.Context(), database.ListWebhookDeliveriesByStatusParams{
+		Limit:  limit,
+		Offset: offset,
+		Status: sql.NullString{String: status, Valid: status != ""},
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list deliveries")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, webhookDeliveriesFromDB(dbDeliveries))
+}
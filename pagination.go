@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageParams reads the limit and offset query parameters shared by every
+// paginated list endpoint.
+func pageParams(request *http.Request) (int32, int32, error) {
+	limit := defaultPageSize
+	offset := 0
+
+	if value := request.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("invalid limit")
+		}
+		limit = min(parsed, maxPageSize)
+	}
+	if value := request.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("invalid offset")
+		}
+		offset = parsed
+	}
+	return int32(limit), int32(offset), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/google/uuid"
+)
+
+// principal is the caller behind a request, with the scopes granted by its
+// access token or API key.
+type principal struct {
+	UserID   uuid.UUID
+	Scopes   []string
+	APIKeyID uuid.UUID
+}
+
+type principalKey struct{}
+
+func principalFromContext(ctx context.Context) principal {
+	caller, _ := ctx.Value(principalKey{}).(principal)
+	return caller
+}
+
+// requireScopes authenticates the request and rejects it with 403, naming the
+// missing scope, unless the caller holds every scope listed.
+func (config *apiConfig) requireScopes(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		caller, err := config.authenticateRequest(request)
+		if err != nil {
+			respondWithError(writer, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if missing := auth.MissingScope(caller.Scopes, scopes...); missing != "" {
+			respondWithError(writer, http.StatusForbidden, fmt.Sprintf("Missing scope: %s", missing))
+			return
+		}
+		next(writer, request.WithContext(context.WithValue(request.Context(), principalKey{}, caller)))
+	}
+}
+
+// authenticateRequest accepts either a Bearer access token or a personal API
+// key sent with the ApiKey scheme.
+func (config *apiConfig) authenticateRequest(request *http.Request) (principal, error) {
+	if strings.HasPrefix(request.Header.Get("Authorization"), "ApiKey ") {
+		key, err := auth.GetAPIKey(request.Header)
+		if err != nil {
+			return principal{}, err
+		}
+		dbKey, err := config.databaseQueries.APIKeyByHash(request.Context(), auth.HashAPIKey(key))
+		if err != nil {
+			return principal{}, errors.New("invalid API key")
+		}
+		config.databaseQueries.TouchAPIKey(request.Context(), dbKey.ID)
+		return principal{
+			UserID:   dbKey.UserID,
+			Scopes:   dbKey.Scopes,
+			APIKeyID: dbKey.ID,
+		}, nil
+	}
+
+	token, err := auth.GetBearerToken(request.Header)
+	if err != nil {
+		return principal{}, err
+	}
+	userId, scopes, err := auth.ValidateScopedJWT(token, config.secret)
+	if err != nil {
+		return principal{}, err
+	}
+	return principal{UserID: userId, Scopes: scopes}, nil
+}
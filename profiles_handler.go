@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/handles"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// Profile is the public view of a user. It must never include the email.
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+var (
+	errHandleTaken    = errors.New("Handle is taken")
+	errHandleCooldown = errors.New("Handle changed too recently")
+)
+
+// userByIDOrHandle looks up a user by ID or handle. Accounts pending
+// deletion are hidden as if already gone.
+func (config *apiConfig) userByIDOrHandle(ctx context.Context, idOrHandle string) (database.User, error) {
+	var dbUser database.User
+	id, err := uuid.Parse(idOrHandle)
+	if err == nil {
+		dbUser, err = config.databaseQueries.UserByID(ctx, id)
+	} else {
+		dbUser, err = config.databaseQueries.UserByHandle(ctx, handles.Normalize(idOrHandle))
+	}
+	if err == nil && dbUser.DeletionRequestedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	return dbUser, err
+}
+
+func (config *apiConfig) profileHandler(writer http.ResponseWriter, request *http.Request) {
+	dbUser, err := config.userByIDOrHandle(request.Context(), request.PathValue("idOrHandle"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, Profile{
+		ID:          dbUser.ID,
+		CreatedAt:   dbUser.CreatedAt,
+		Handle:      dbUser.Handle.String,
+		DisplayName: dbUser.DisplayName,
+		Bio:         dbUser.Bio,
+		AvatarURL:   dbUser.AvatarURL,
+		IsChirpyRed: config.isChirpyRed(request.Context(), dbUser.ID),
+	})
+}
+
+// updateProfileHandler changes whichever profile fields are present in the
+// body. A new handle is subject to the rules in the handles package, and the
+// old one is reserved for this user.
+func (config *apiConfig) updateProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	dbUser, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "User not found")
+		return
+	}
+
+	displayName, bio, avatarURL := dbUser.DisplayName, dbUser.Bio, dbUser.AvatarURL
+	if params.DisplayName != nil {
+		displayName = *params.DisplayName
+	}
+	if params.Bio != nil {
+		bio = *params.Bio
+	}
+	if params.AvatarURL != nil {
+		avatarURL = *params.AvatarURL
+	}
+	if len([]rune(displayName)) > maxDisplayNameLength {
+		respondWithError(writer, http.StatusBadRequest, "Display name is too long")
+		return
+	}
+	if len([]rune(bio)) > maxBioLength {
+		respondWithError(writer, http.StatusBadRequest, "Bio is too long")
+		return
+	}
+	if avatarURL != "" {
+		parsed, err := url.Parse(avatarURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			respondWithError(writer, http.StatusBadRequest, "Avatar URL must be https")
+			return
+		}
+	}
+	if params.Handle != nil {
+		err = handles.Validate(*params.Handle)
+		if err != nil {
+			respondWithError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		dbUser, err = queries.UpdateProfile(request.Context(), database.UpdateProfileParams{
+			ID:          userId,
+			DisplayName: displayName,
+			Bio:         bio,
+			AvatarURL:   avatarURL,
+		})
+		if err != nil {
+			return err
+		}
+		if params.Handle != nil && *params.Handle != dbUser.Handle.String {
+			dbUser, err = changeHandle(request.Context(), queries, dbUser, *params.Handle)
+		}
+		return err
+	})
+	if errors.Is(err, errHandleTaken) || errors.Is(err, errHandleCooldown) {
+		respondWithError(writer, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't update profile")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusOK, config.userFromDB(request.Context(), dbUser))
+}
+
+func changeHandle(ctx context.Context, queries *database.Queries, dbUser database.User, handle string) (database.User, error) {
+	normalized := handles.Normalize(handle)
+	previous := handles.Normalize(dbUser.Handle.String)
+
+	// Changing only the capitalisation isn't a new handle.
+	if normalized != previous && dbUser.HandleChangedAt.Valid {
+		allowed, next := handles.CanChange(dbUser.HandleChangedAt.Time, time.Now().UTC())
+		if !allowed {
+			return dbUser, fmt.Errorf("%w; try again after %s", errHandleCooldown, next.Format(time.RFC3339))
+		}
+	}
+
+	reservation, err := queries.HandleReservation(ctx, normalized)
+	if err == nil && reservation.UserID != dbUser.ID {
+		return dbUser, errHandleTaken
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return dbUser, err
+	}
+
+	updated, err := queries.SetHandle(ctx, database.SetHandleParams{
+		ID:     dbUser.ID,
+		Handle: sql.NullString{String: handle, Valid: true},
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return dbUser, errHandleTaken
+	}
+	if err != nil {
+		return dbUser, err
+	}
+
+	err = queries.DelHandleReservation(ctx, normalized)
+	if err != nil {
+		return dbUser, err
+	}
+	if previous != "" && previous != normalized {
+		err = queries.ReserveHandle(ctx, database.ReserveHandleParams{
+			Handle:        previous,
+			UserID:        dbUser.ID,
+			ReservedUntil: time.Now().UTC().Add(handles.ReservationPeriod),
+		})
+	}
+	return updated, err
+}
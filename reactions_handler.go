@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+)
+
+// knownReactions are the reactions a chirp accepts. Each user has at most one
+// reaction per chirp; reacting again replaces it.
+var knownReactions = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+type ReactionCount struct {
+	Reaction string `json:"reaction"`
+	Count    int64  `json:"count"`
+}
+
+// reactionTarget resolves the chirp named in the path, following a plain
+// rechirp to its original the way rechirpHandler does.
+func (config *apiConfig) reactionTarget(request *http.Request) (database.Chirp, error) {
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	dbChirp, err := config.databaseQueries.SingleChirp(request.Context(), id)
+	if err == nil && isRechirp(dbChirp) {
+		dbChirp, err = config.databaseQueries.SingleChirp(request.Context(), dbChirp.RepostOfID.UUID)
+	}
+	return dbChirp, err
+}
+
+// reactHandler sets the caller's reaction to a chirp and notifies its author
+// the first time the caller reacts to it.
+func (config *apiConfig) reactHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Reaction string `json:"reaction"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if !slices.Contains(knownReactions, params.Reaction) {
+		respondWithError(writer, http.StatusBadRequest, "Unknown reaction")
+		return
+	}
+
+	dbChirp, err := config.reactionTarget(request)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		err := queries.SetReaction(request.Context(), database.SetReactionParams{
+			ChirpID:  dbChirp.ID,
+			UserID:   userId,
+			Reaction: params.Reaction,
+		})
+		if err != nil {
+			return err
+		}
+		return notify(request.Context(), queries, dbChirp.UserID, userId, notificationReaction, dbChirp.ID)
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't save reaction")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (config *apiConfig) unreactHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	dbChirp, err := config.reactionTarget(request)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	count, err := config.databaseQueries.DelReaction(request.Context(), database.DelReactionParams{
+		ChirpID: dbChirp.ID,
+		UserID:  userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't delete reaction")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Reaction not found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// reactionsHandler returns how many of each reaction a chirp has.
+func (config *apiConfig) reactionsHandler(writer http.ResponseWriter, request *http.Request) {
+	dbChirp, err := config.reactionTarget(request)
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	rows, err := config.databaseQueries.ReactionCounts(request.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't count reactions")
+		return
+	}
+
+	counts := []ReactionCount{}
+	for _, row := range rows {
+		counts = append(counts, ReactionCount{Reaction: row.Reaction, Count: row.Count})
+	}
+	respondWithJSON(writer, http.StatusOK, counts)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ChirpTombstone is embedded in place of a reposted chirp that has since
+// been deleted.
+type ChirpTombstone struct {
+	ID      uuid.UUID `json:"id"`
+	Deleted bool      `json:"deleted"`
+}
+
+// isRechirp reports whether dbChirp is a plain rechirp: a repost with no
+// commentary of its own. A repost with a body is a quote.
+func isRechirp(dbChirp database.Chirp) bool {
+	return dbChirp.RepostOfID.Valid && dbChirp.Body == ""
+}
+
+func (config *apiConfig) repostCountsFor(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID]database.RepostCountsRow, error) {
+	counts := map[uuid.UUID]database.RepostCountsRow{}
+	if len(chirpIDs) == 0 {
+		return counts, nil
+	}
+	rows, err := config.databaseQueries.RepostCounts(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ChirpID] = row
+	}
+	return counts, nil
+}
+
+// embedReposts fills in RepostOf on every repost in chirps.
+func (config *apiConfig) embedReposts(ctx context.Context, chirps []Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RepostOfID != nil {
+			ids = append(ids, *chirp.RepostOfID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	dbOriginals, err := config.databaseQueries.ChirpsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	rendered, err := config.renderChirpsOnly(ctx, dbOriginals)
+	if err != nil {
+		return err
+	}
+	originals := map[uuid.UUID]Chirp{}
+	for _, original := range rendered {
+		originals[original.ID] = original
+	}
+
+	for i, chirp := range chirps {
+		if chirp.RepostOfID == nil {
+			continue
+		}
+		if original, ok := originals[*chirp.RepostOfID]; ok {
+			chirps[i].RepostOf = original
+		} else {
+			chirps[i].RepostOf = ChirpTombstone{ID: *chirp.RepostOfID, Deleted: true}
+		}
+	}
+	return nil
+}
+
+// rechirpHandler reposts a chirp. With no body it's a plain rechirp, which a
+// user can make once per chirp; with a body it's a quote, validated and
+// censored like any other chirp. Rechirping a rechirp reposts its original.
+func (config *apiConfig) rechirpHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	original, err := config.databaseQueries.SingleChirp(request.Context(), id)
+	if err == nil && isRechirp(original) {
+		original, err = config.databaseQueries.SingleChirp(request.Context(), original.RepostOfID.UUID)
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No Chirp found")
+		return
+	}
+
+	body := ""
+	if params.Body != "" {
+		body, err = validateChirpBody(params.Body, config.entitlementsFor(request.Context(), userId))
+		if err != nil {
+			respondWithError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	parsed, err := config.parseChirpEntities(request.Context(), body)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't parse chirp")
+		return
+	}
+
+	var returnChirp Chirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		returnChirp, err = config.insertChirp(request.Context(), queries, database.CreateChirpParams{
+			Body:       body,
+			UserID:     userId,
+			Entities:   parsed.JSON(),
+			RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		}, parsed, nil)
+		return err
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(writer, http.StatusConflict, "Already rechirped")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not created")
+		return
+	}
+
+	returnChirps := []Chirp{returnChirp}
+	err = config.embedReposts(request.Context(), returnChirps)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Chirp not retrieved")
+		return
+	}
+	respondWithJSON(writer, http.StatusCreated, returnChirps[0])
+}
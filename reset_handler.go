@@ -1,17 +1,95 @@
 package main
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/fixtures"
+	"github.com/google/uuid"
 )
 
+const maxFixtureBytes = 1 << 20
+
+// requireDisposable guards the reset and seed endpoints: besides needing an
+// admin, they only run on the dev platform against a database marked
+// disposable.
+func (config *apiConfig) requireDisposable(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if config.platform != "dev" {
+			respondWithError(writer, http.StatusForbidden, "Not allowed")
+			return
+		}
+		err := fixtures.CheckDisposable(request.Context(), config.db)
+		if errors.Is(err, fixtures.ErrNotDisposable) {
+			respondWithError(writer, http.StatusForbidden, err.Error())
+			return
+		}
+		if err != nil {
+			respondWithError(writer, http.StatusInternalServerError, "Couldn't check database")
+			return
+		}
+		next(writer, request)
+	}
+}
+
+// resetHandler truncates the scopes listed in the body, or just "users" (and
+// so everything users own) when there's no body.
 func (config *apiConfig) resetHandler(writer http.ResponseWriter, request *http.Request) {
-	if config.platform != "dev" {
-		fmt.Println("Not Allowed")
-		writer.WriteHeader(403)
+	type parameters struct {
+		Scopes []string `json:"scopes"`
+	}
+
+	params := parameters{}
+	decoder := json.NewDecoder(request.Body)
+	err := decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if len(params.Scopes) == 0 {
+		params.Scopes = []string{"users"}
+	}
+
+	err = fixtures.Truncate(request.Context(), config.db, params.Scopes)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
 		return
 	}
-	config.databaseQueries.DelUsers(request.Context())
-	writer.WriteHeader(200)
-	config.fileserverHits.Store(0)
+	config.audit(request.Context(), auditAdminReset, principalFromContext(request.Context()).UserID, uuid.Nil, params)
+
+	respondWithJSON(writer, http.StatusOK, params)
+}
+
+// seedHandler loads a YAML fixture from the body. With ?reset=true the users
+// scope is truncated first so the same fixture can be loaded repeatedly.
+func (config *apiConfig) seedHandler(writer http.ResponseWriter, request *http.Request) {
+	fixture, err := fixtures.Parse(http.MaxBytesReader(writer, request.Body, maxFixtureBytes))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if request.URL.Query().Get("reset") == "true" {
+		err = fixtures.Truncate(request.Context(), config.db, []string{"users"})
+		if err != nil {
+			respondWithError(writer, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	var counts fixtures.Counts
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		counts, err = fixtures.Seed(request.Context(), queries, fixture)
+		return err
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusConflict, err.Error())
+		return
+	}
+	config.audit(request.Context(), auditAdminSeed, principalFromContext(request.Context()).UserID, uuid.Nil, counts)
+
+	respondWithJSON(writer, http.StatusCreated, counts)
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/jobs"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxScheduleAhead is how far in the future a chirp can be scheduled.
+	maxScheduleAhead = 365 * 24 * time.Hour
+
+	jobKindPublishChirp = "chirps.publish"
+)
+
+type ScheduledChirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+	PublishAt time.Time  `json:"publish_at"`
+}
+
+func scheduledChirpFromDB(dbScheduled database.ScheduledChirp) ScheduledChirp {
+	scheduled := ScheduledChirp{
+		ID:        dbScheduled.ID,
+		CreatedAt: dbScheduled.CreatedAt,
+		UpdatedAt: dbScheduled.UpdatedAt,
+		Body:      dbScheduled.Body,
+		UserID:    dbScheduled.UserID,
+		PublishAt: dbScheduled.PublishAt,
+	}
+	if dbScheduled.ReplyToID.Valid {
+		scheduled.ReplyToID = &dbScheduled.ReplyToID.UUID
+	}
+	return scheduled
+}
+
+type publishChirpJob struct {
+	ScheduledID uuid.UUID `json:"scheduled_id"`
+}
+
+// scheduleChirp handles a POST /api/chirps with a publish_at time. The body
+// is stored as written and only censored when it's published, so the filter
+// in force at that time applies.
+func (config *apiConfig) scheduleChirp(writer http.ResponseWriter, request *http.Request, params chirpUpload) {
+	userId := principalFromContext(request.Context()).UserID
+
+	publishAt := params.PublishAt.UTC()
+	if !publishAt.After(time.Now()) {
+		respondWithError(writer, http.StatusBadRequest, "publish_at must be in the future")
+		return
+	}
+	if publishAt.After(time.Now().Add(maxScheduleAhead)) {
+		respondWithError(writer, http.StatusBadRequest, "publish_at is too far in the future")
+		return
+	}
+	if len(params.Images) > 0 {
+		respondWithError(writer, http.StatusBadRequest, "Scheduled chirps can't have attachments")
+		return
+	}
+	_, err := validateChirpBody(params.Body, config.entitlementsFor(request.Context(), userId))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	replyTo, err := config.replyTarget(request.Context(), params.ReplyToID)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Reply target not found")
+		return
+	}
+
+	var dbScheduled database.ScheduledChirp
+	err = config.withTx(request.Context(), func(queries *database.Queries) error {
+		dbScheduled, err = queries.CreateScheduledChirp(request.Context(), database.CreateScheduledChirpParams{
+			UserID:    userId,
+			Body:      params.Body,
+			ReplyToID: replyTo,
+			PublishAt: publishAt,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = jobs.Enqueue(request.Context(), queries, jobKindPublishChirp, publishChirpJob{
+			ScheduledID: dbScheduled.ID,
+		}, publishAt)
+		return err
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't schedule chirp")
+		return
+	}
+
+	respondWithJSON(writer, http.StatusCreated, scheduledChirpFromDB(dbScheduled))
+}
+
+// listScheduledChirpsHandler pages through the caller's scheduled chirps,
+// soonest first.
+func (config *apiConfig) listScheduledChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbScheduled, err := config.databaseQueries.UserScheduledChirps(request.Context(), database.UserScheduledChirpsParams{
+		UserID: userId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list scheduled chirps")
+		return
+	}
+
+	scheduled := []ScheduledChirp{}
+	for _, row := range dbScheduled {
+		scheduled = append(scheduled, scheduledChirpFromDB(row))
+	}
+	respondWithJSON(writer, http.StatusOK, scheduled)
+}
+
+// cancelScheduledChirpHandler drops a scheduled chirp before it's published.
+// Its publish job finds nothing to do and completes.
+func (config *apiConfig) cancelScheduledChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userId := principalFromContext(request.Context()).UserID
+
+	scheduledId, err := uuid.Parse(request.PathValue("scheduledID"))
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid scheduled chirp ID")
+		return
+	}
+
+	count, err := config.databaseQueries.CancelScheduledChirp(request.Context(), database.CancelScheduledChirpParams{
+		ID:     scheduledId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't cancel scheduled chirp")
+		return
+	}
+	if count == 0 {
+		respondWithError(writer, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// publishChirpJob turns a scheduled chirp into a real one. Taking the row
+// deletes it in the same transaction that creates the chirp, so a job that
+// runs twice, or on two replicas at once, publishes the chirp only once.
+// Whatever made it valid when scheduled is checked again: if the author is
+// being deleted or the chirp it replies to is gone, the row is dropped
+// unpublished.
+func (config *apiConfig) publishChirpJob(ctx context.Context, payload json.RawMessage) error {
+	job := publishChirpJob{}
+	err := json.Unmarshal(payload, &job)
+	if err != nil {
+		return err
+	}
+
+	return config.withTx(ctx, func(queries *database.Queries) error {
+		dbScheduled, err := queries.TakeScheduledChirp(ctx, job.ScheduledID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		dbUser, err := queries.UserByID(ctx, dbScheduled.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if dbUser.DeletionRequestedAt.Valid {
+			fmt.Printf("Dropped scheduled chirp %s: author is being deleted\n", dbScheduled.ID)
+			return nil
+		}
+		if dbScheduled.ReplyToID.Valid {
+			_, err = config.replyTarget(ctx, &dbScheduled.ReplyToID.UUID)
+			if errors.Is(err, sql.ErrNoRows) {
+				fmt.Printf("Dropped scheduled chirp %s: reply target %s is gone\n", dbScheduled.ID, dbScheduled.ReplyToID.UUID)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		body := censorMessage(dbScheduled.Body)
+		parsed, err := config.parseChirpEntities(ctx, body)
+		if err != nil {
+			return err
+		}
+		_, err = config.insertChirp(ctx, queries, database.CreateChirpParams{
+			Body:      body,
+			UserID:    dbScheduled.UserID,
+			ReplyToID: dbScheduled.ReplyToID,
+			Entities:  parsed.JSON(),
+		}, parsed, nil)
+		return err
+	})
+}
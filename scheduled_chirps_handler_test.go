@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+var userColumns = []string{"id", "created_at", "updated_at", "email", "hashed_password", "totp_secret", "totp_enabled",
+	"is_admin", "handle", "display_name", "bio", "avatar_url", "handle_changed_at", "deletion_requested_at"}
+
+func userRow(id uuid.UUID, deletionRequestedAt interface{}) []driver.Value {
+	now := time.Now().UTC()
+	return []driver.Value{id.String(), now, now, "a@example.com", nil, nil, false, false, nil, "", "", "", nil, deletionRequestedAt}
+}
+
+func TestPublishChirpJobDropsStale(t *testing.T) {
+	tests := []struct {
+		name          string
+		deletionAt    interface{}
+		parentDeleted bool
+	}{
+		{name: "Author being deleted", deletionAt: time.Now().UTC()},
+		{name: "Reply target deleted", parentDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorID, parentID, scheduledID := uuid.New(), uuid.New(), uuid.New()
+
+			db := dbtest.New()
+			db.Handle("TakeScheduledChirp", func(args []driver.Value) (dbtest.Rows, error) {
+				now := time.Now().UTC()
+				return dbtest.Rows{
+					Columns: []string{"id", "created_at", "updated_at", "user_id", "body", "reply_to_id", "publish_at"},
+					Values:  [][]driver.Value{{scheduledID.String(), now, now, authorID.String(), "hi", parentID.String(), now}},
+				}, nil
+			})
+			db.Handle("UserByID", func(args []driver.Value) (dbtest.Rows, error) {
+				return dbtest.Rows{Columns: userColumns, Values: [][]driver.Value{userRow(authorID, tt.deletionAt)}}, nil
+			})
+			db.Handle("SingleChirp", func(args []driver.Value) (dbtest.Rows, error) {
+				if tt.parentDeleted {
+					return dbtest.Rows{Columns: []string{"id"}}, nil
+				}
+				now := time.Now().UTC()
+				return dbtest.Rows{
+					Columns: []string{"id", "created_at", "updated_at", "body", "user_id", "reply_to_id", "entities", "deleted_at", "repost_of_id"},
+					Values:  [][]driver.Value{{parentID.String(), now, now, "parent", uuid.NewString(), nil, []byte(`[]`), nil, nil}},
+				}, nil
+			})
+			config := apiConfig{db: db.DB, databaseQueries: database.New(db)}
+
+			payload, _ := json.Marshal(publishChirpJob{ScheduledID: scheduledID})
+			err := config.publishChirpJob(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("publishChirpJob() error = %v", err)
+			}
+			if calls := db.Calls("TakeScheduledChirp"); len(calls) != 1 {
+				t.Errorf("TakeScheduledChirp committed %d times, want 1", len(calls))
+			}
+			if calls := db.Calls("CreateChirp"); len(calls) != 0 {
+				t.Errorf("CreateChirp committed %d times, want 0", len(calls))
+			}
+		})
+	}
+}
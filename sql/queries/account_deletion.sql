@@ -0,0 +1,20 @@
+-- name: RequestUserDeletion :one
+update users set deletion_requested_at = NOW(), updated_at = NOW() where id = $1
+returning *;
+
+-- name: CancelUserDeletion :one
+update users set deletion_requested_at = NULL, updated_at = NOW()
+where id = $1 and deletion_requested_at is not null
+returning *;
+
+-- name: DelUser :exec
+delete from users where id = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() where user_id = $1 and revoked_at is null;
+
+-- name: RevokeUserAPIKeys :exec
+UPDATE api_keys SET revoked_at = NOW() where user_id = $1 and revoked_at is null;
+
+-- name: UserChirpIDs :many
+select id from chirps where user_id = $1;
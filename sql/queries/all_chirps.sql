@@ -1,5 +1,5 @@
 -- name: AllChirps :many
-select * from chirps order by created_at;
+select * from chirps where deleted_at is null order by created_at;
 
 -- name: AllChirpsAuthorID :many
-select * from chirps where user_id = $1;
\ No newline at end of file
+select * from chirps where user_id = $1 and deleted_at is null;
\ No newline at end of file
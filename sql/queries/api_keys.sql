@@ -0,0 +1,16 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, created_at, name, key_prefix, key_hash, scopes, user_id)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListAPIKeys :many
+select * from api_keys where user_id = $1 order by created_at;
+
+-- name: APIKeyByHash :one
+select * from api_keys where key_hash = $1 and revoked_at is null;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW() where id = $1 and user_id = $2 and revoked_at is null;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() where id = $1;
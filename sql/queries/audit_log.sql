@@ -0,0 +1,14 @@
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (created_at, action, actor_id, target_id, ip, user_agent, request_id, details)
+VALUES (NOW(), $1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditEntries :many
+select * from audit_log
+where (sqlc.narg('action')::text is null or action = sqlc.narg('action')::text)
+and (sqlc.narg('actor_id')::uuid is null or actor_id = sqlc.narg('actor_id')::uuid)
+and (sqlc.narg('target_id')::uuid is null or target_id = sqlc.narg('target_id')::uuid)
+and (sqlc.narg('ip')::text is null or ip = sqlc.narg('ip')::text)
+and (sqlc.narg('since')::timestamp is null or created_at >= sqlc.narg('since')::timestamp)
+and (sqlc.narg('until')::timestamp is null or created_at < sqlc.narg('until')::timestamp)
+order by id desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
@@ -0,0 +1,34 @@
+-- name: AddBookmark :one
+INSERT INTO bookmarks (user_id, chirp_id, created_at, collection_id)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET collection_id = excluded.collection_id
+RETURNING *;
+
+-- name: DelBookmark :execrows
+delete from bookmarks where user_id = $1 and chirp_id = $2;
+
+-- name: UserBookmarks :many
+select bookmarks.* from bookmarks join chirps on chirps.id = bookmarks.chirp_id
+where bookmarks.user_id = sqlc.arg('user_id') and chirps.deleted_at is null
+and (sqlc.narg('collection_id')::uuid is null or bookmarks.collection_id = sqlc.narg('collection_id')::uuid)
+order by bookmarks.created_at desc, bookmarks.chirp_id
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: CreateCollection :one
+INSERT INTO collections (id, created_at, updated_at, user_id, name)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: Collection :one
+select * from collections where id = $1 and user_id = $2;
+
+-- name: UserCollections :many
+select * from collections where user_id = $1 order by name;
+
+-- name: RenameCollection :one
+update collections set name = $3, updated_at = NOW()
+where id = $1 and user_id = $2
+returning *;
+
+-- name: DelCollection :execrows
+delete from collections where id = $1 and user_id = $2;
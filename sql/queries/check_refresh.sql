@@ -1,5 +1,8 @@
 -- name: CheckRefresh :one
-select user_id from refresh_tokens where token = $1 and expires_at < NOW() and revoked_at is null;
+select user_id, scopes from refresh_tokens where token = $1 and expires_at > NOW() and revoked_at is null;
 
 -- name: UpdateRevocation :exec
-UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() where token = $1;
\ No newline at end of file
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() where token = $1;
+
+-- name: RefreshTokenUser :one
+select user_id from refresh_tokens where token = $1;
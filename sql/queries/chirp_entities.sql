@@ -0,0 +1,36 @@
+-- name: AddChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+select $1, unnest(sqlc.arg('tags')::text[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: AddChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+select $1, unnest(sqlc.arg('user_ids')::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: DelChirpHashtags :exec
+delete from chirp_hashtags where chirp_id = $1;
+
+-- name: DelChirpMentions :exec
+delete from chirp_mentions where chirp_id = $1;
+
+-- name: ChirpMentions :many
+select user_id from chirp_mentions where chirp_id = $1;
+
+-- name: UsersByEmails :many
+select id, lower(email)::text as email from users where lower(email) = ANY(sqlc.arg('emails')::text[]);
+
+-- name: HashtagChirps :many
+select chirps.* from chirps
+join chirp_hashtags on chirp_hashtags.chirp_id = chirps.id
+where chirp_hashtags.tag = sqlc.arg('tag') and chirps.deleted_at is null
+order by chirps.created_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: TrendingHashtags :many
+select chirp_hashtags.tag, count(*) as uses from chirp_hashtags
+join chirps on chirps.id = chirp_hashtags.chirp_id
+where chirp_hashtags.created_at > $1 and chirps.deleted_at is null
+group by chirp_hashtags.tag
+order by uses desc, chirp_hashtags.tag
+limit $2;
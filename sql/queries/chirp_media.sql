@@ -0,0 +1,7 @@
+-- name: CreateChirpMedia :one
+INSERT INTO chirp_media (id, created_at, chirp_id, position, content_type, width, height, blob_key, thumb_key)
+VALUES ($1, NOW(), $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: MediaForChirps :many
+select * from chirp_media where chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]) order by chirp_id, position;
@@ -0,0 +1,19 @@
+-- name: CreateChirpStreamEvent :one
+INSERT INTO chirp_stream_events (created_at, event, chirp_id, user_id, payload, recipient_id)
+VALUES (NOW(), $1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: NotifyChirpStream :exec
+select pg_notify('chirp_stream', sqlc.arg(event_id)::text);
+
+-- name: ChirpStreamEventsAfter :many
+select * from chirp_stream_events
+where id > sqlc.arg(after_id) and id <= sqlc.arg(until_id)
+order by id
+limit sqlc.arg('limit');
+
+-- name: LatestChirpStreamEventID :one
+select coalesce(max(id), 0)::bigint from chirp_stream_events;
+
+-- name: PruneChirpStreamEvents :exec
+delete from chirp_stream_events where created_at < $1;
@@ -1,4 +1,4 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING *;
\ No newline at end of file
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, entities, repost_of_id)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
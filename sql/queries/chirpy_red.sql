@@ -1,2 +0,0 @@
--- name: UpdateUsersRed :exec
-UPDATE users SET is_chirpy_red = true where id = $1;
\ No newline at end of file
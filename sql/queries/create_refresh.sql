@@ -1,4 +1,4 @@
 -- name: CreateRefresh :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-VALUES ($1, NOW(), NOW(), $2, $3)
-RETURNING *;
\ No newline at end of file
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, scopes)
+VALUES ($1, NOW(), NOW(), $2, $3, $4)
+RETURNING *;
@@ -0,0 +1,16 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, user_id)
+VALUES (gen_random_uuid(), NOW(), $1)
+RETURNING *;
+
+-- name: LatestDataExport :one
+select * from data_exports where user_id = $1 order by created_at desc limit 1;
+
+-- name: CompleteDataExport :exec
+update data_exports set completed_at = NOW(), archive = $2 where id = $1;
+
+-- name: DelDataExports :exec
+delete from data_exports where user_id = $1;
+
+-- name: UserRefreshTokens :many
+select created_at, expires_at, revoked_at, scopes from refresh_tokens where user_id = $1 order by created_at;
@@ -1,3 +1,14 @@
 -- name: DelChirp :exec
 
-delete from chirps where id = $1;
\ No newline at end of file
+delete from chirps where id = $1;
+
+-- name: SoftDelChirp :one
+update chirps set deleted_at = NOW() where id = $1 and deleted_at is null
+returning *;
+
+-- name: RestoreChirp :one
+update chirps set deleted_at = NULL where id = $1 and deleted_at > $2
+returning *;
+
+-- name: ChirpIncludingDeleted :one
+select * from chirps where id = $1;
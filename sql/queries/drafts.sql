@@ -0,0 +1,20 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, reply_to_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
+RETURNING *;
+
+-- name: UpdateDraft :one
+update drafts set body = $3, reply_to_id = $4, updated_at = NOW()
+where id = $1 and user_id = $2
+returning *;
+
+-- name: Draft :one
+select * from drafts where id = $1 and user_id = $2;
+
+-- name: UserDrafts :many
+select * from drafts where user_id = $1
+order by updated_at desc, id
+limit $2 offset $3;
+
+-- name: DelDraft :execrows
+delete from drafts where id = $1 and user_id = $2;
@@ -0,0 +1,13 @@
+-- name: SeedUser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, display_name, bio, is_admin)
+VALUES ($1, $2, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: SeedChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, entities)
+VALUES ($1, $2, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: SeedFollow :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, $3);
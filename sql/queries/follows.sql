@@ -0,0 +1,23 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+delete from follows where follower_id = $1 and followee_id = $2;
+
+-- name: UserFollowers :many
+select users.id, users.handle, users.display_name, follows.created_at as followed_at
+from follows
+join users on users.id = follows.follower_id
+where follows.followee_id = $1 and users.deletion_requested_at is null
+order by follows.created_at desc
+limit $2 offset $3;
+
+-- name: UserFollowing :many
+select users.id, users.handle, users.display_name, follows.created_at as followed_at
+from follows
+join users on users.id = follows.followee_id
+where follows.follower_id = $1 and users.deletion_requested_at is null
+order by follows.created_at desc
+limit $2 offset $3;
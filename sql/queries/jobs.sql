@@ -0,0 +1,38 @@
+-- name: CreateJob :one
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, status, max_attempts, run_at)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, 'pending', $3, $4)
+RETURNING *;
+
+-- name: ClaimJob :one
+UPDATE jobs SET status = 'running', locked_at = NOW(), attempts = attempts + 1, updated_at = NOW()
+where id = (
+    select id from jobs
+    where (status = 'pending' and run_at <= NOW())
+    or (status = 'running' and locked_at < NOW() - interval '5 minutes')
+    order by run_at
+    limit 1
+    for update skip locked
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs SET status = 'succeeded', locked_at = null, last_error = null, completed_at = NOW(), updated_at = NOW()
+where id = $1;
+
+-- name: FailJob :exec
+UPDATE jobs SET status = $2, run_at = $3, last_error = $4, locked_at = null, updated_at = NOW()
+where id = $1;
+
+-- name: Job :one
+select * from jobs where id = $1;
+
+-- name: ListJobs :many
+select * from jobs
+where (sqlc.narg('status')::text is null or status = sqlc.narg('status')::text)
+and (sqlc.narg('kind')::text is null or kind = sqlc.narg('kind')::text)
+order by created_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: RetryJob :execrows
+UPDATE jobs SET status = 'pending', attempts = 0, run_at = NOW(), updated_at = NOW()
+where id = $1 and status = 'dead';
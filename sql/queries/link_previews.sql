@@ -0,0 +1,27 @@
+-- name: AddChirpLinks :exec
+INSERT INTO chirp_links (chirp_id, position, url)
+select $1, links.ordinality - 1, links.url
+from unnest(sqlc.arg('urls')::text[]) with ordinality as links(url, ordinality);
+
+-- name: DelChirpLinks :exec
+delete from chirp_links where chirp_id = $1;
+
+-- name: LinkPreviewFetchedAt :one
+select fetched_at from link_previews where url = $1;
+
+-- name: SaveLinkPreview :exec
+INSERT INTO link_previews (url, fetched_at, ok, title, description, image_url)
+VALUES ($1, NOW(), $2, $3, $4, $5)
+ON CONFLICT (url) DO UPDATE SET
+    fetched_at = EXCLUDED.fetched_at,
+    ok = EXCLUDED.ok,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url;
+
+-- name: PreviewsForChirps :many
+select distinct on (chirp_links.chirp_id) chirp_links.chirp_id, link_previews.url, link_previews.title, link_previews.description, link_previews.image_url
+from chirp_links
+join link_previews on link_previews.url = chirp_links.url
+where chirp_links.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]) and link_previews.ok
+order by chirp_links.chirp_id, chirp_links.position;
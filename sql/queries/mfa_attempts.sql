@@ -0,0 +1,20 @@
+-- name: MFAAttempts :one
+SELECT * FROM mfa_attempts where user_id = $1;
+
+-- name: RecordMFAFailure :one
+INSERT INTO mfa_attempts (user_id, failed_count)
+VALUES ($1, 1)
+ON CONFLICT (user_id) DO UPDATE SET failed_count = mfa_attempts.failed_count + 1
+RETURNING failed_count;
+
+-- name: LockMFA :exec
+UPDATE mfa_attempts SET failed_count = 0, locked_until = $2 where user_id = $1;
+
+-- name: ResetMFAFailures :exec
+UPDATE mfa_attempts SET failed_count = 0, locked_until = NULL where user_id = $1;
+
+-- name: UseTOTPStep :execrows
+INSERT INTO mfa_attempts (user_id, last_totp_step)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET last_totp_step = excluded.last_totp_step
+WHERE mfa_attempts.last_totp_step < excluded.last_totp_step;
@@ -0,0 +1,28 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: ListNotifications :many
+select notifications.* from notifications
+left join chirps on chirps.id = notifications.chirp_id
+where notifications.user_id = sqlc.arg('user_id')
+and (notifications.chirp_id is null or chirps.deleted_at is null)
+and (not sqlc.arg('unread_only')::bool or notifications.read_at is null)
+order by notifications.created_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: CountUnreadNotifications :one
+select count(*) from notifications
+left join chirps on chirps.id = notifications.chirp_id
+where notifications.user_id = $1 and notifications.read_at is null
+and (notifications.chirp_id is null or chirps.deleted_at is null);
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications SET read_at = NOW()
+where user_id = $1 and id = ANY(sqlc.arg('ids')::uuid[]) and read_at is null;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = NOW()
+where user_id = $1 and read_at is null;
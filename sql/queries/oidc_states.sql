@@ -0,0 +1,7 @@
+-- name: CreateOIDCState :exec
+INSERT INTO oidc_login_states (state, code_verifier, nonce, created_at, expires_at)
+VALUES ($1, $2, $3, NOW(), $4);
+
+-- name: ConsumeOIDCState :one
+delete from oidc_login_states where state = $1 and expires_at > NOW()
+returning *;
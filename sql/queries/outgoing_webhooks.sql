@@ -0,0 +1,54 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (id, created_at, updated_at, url, secret, events, active, user_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, true, $4)
+RETURNING *;
+
+-- name: WebhookSubscription :one
+select * from webhook_subscriptions where id = $1;
+
+-- name: ListWebhookSubscriptions :many
+select * from webhook_subscriptions where user_id = $1 order by created_at;
+
+-- name: DelWebhookSubscription :execrows
+delete from webhook_subscriptions where id = $1 and user_id = $2;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (id, created_at, updated_at, event, payload, status, next_attempt_at, subscription_id)
+SELECT gen_random_uuid(), NOW(), NOW(), sqlc.arg(event)::text, sqlc.arg(payload)::jsonb, 'pending', NOW(), id
+FROM webhook_subscriptions
+WHERE active
+and sqlc.arg(event)::text = any(events)
+and (user_id is null or user_id = sqlc.arg(user_id)::uuid);
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::int), updated_at = NOW()
+where id in (
+    select id from webhook_deliveries
+    where status = 'pending' and next_attempt_at <= NOW()
+    order by next_attempt_at
+    limit sqlc.arg('limit')
+    for update skip locked
+)
+RETURNING *;
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, response_status = $2, last_error = null, delivered_at = NOW(), updated_at = NOW()
+where id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4, response_status = $5, updated_at = NOW()
+where id = $1;
+
+-- name: ListWebhookDeliveries :many
+select * from webhook_deliveries
+where subscription_id = $1
+order by created_at desc
+limit $2 offset $3;
+
+-- name: ListWebhookDeliveriesByStatus :many
+select * from webhook_deliveries
+where sqlc.narg('status')::text is null or status = sqlc.narg('status')::text
+order by created_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
@@ -0,0 +1,27 @@
+-- name: UserByHandle :one
+SELECT * FROM users where lower(handle) = lower($1);
+
+-- name: UsersByHandles :many
+select id, lower(handle)::text as handle from users where lower(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: UpdateProfile :one
+update users set display_name = $2, bio = $3, avatar_url = $4, updated_at = NOW() where id = $1
+returning *;
+
+-- name: SetHandle :one
+update users set handle = $2,
+handle_changed_at = CASE WHEN lower(handle) = lower($2) THEN handle_changed_at ELSE NOW() END,
+updated_at = NOW()
+where id = $1
+returning *;
+
+-- name: HandleReservation :one
+select * from handle_reservations where handle = $1 and reserved_until > NOW();
+
+-- name: ReserveHandle :exec
+INSERT INTO handle_reservations (handle, user_id, reserved_until)
+VALUES ($1, $2, $3)
+ON CONFLICT (handle) DO UPDATE SET user_id = EXCLUDED.user_id, reserved_until = EXCLUDED.reserved_until;
+
+-- name: DelHandleReservation :exec
+delete from handle_reservations where handle = $1;
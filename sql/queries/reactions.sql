@@ -0,0 +1,13 @@
+-- name: SetReaction :exec
+INSERT INTO chirp_reactions (chirp_id, user_id, reaction, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (chirp_id, user_id) DO UPDATE SET reaction = excluded.reaction, created_at = NOW();
+
+-- name: DelReaction :execrows
+delete from chirp_reactions where chirp_id = $1 and user_id = $2;
+
+-- name: ReactionCounts :many
+select reaction, count(*) as count from chirp_reactions
+where chirp_id = $1
+group by reaction
+order by reaction;
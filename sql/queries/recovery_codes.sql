@@ -0,0 +1,9 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (code_hash, created_at, user_id)
+VALUES ($1, NOW(), $2);
+
+-- name: DelRecoveryCodes :exec
+delete from mfa_recovery_codes where user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes SET used_at = NOW() where user_id = $1 and code_hash = $2 and used_at is null;
@@ -0,0 +1,10 @@
+-- name: ChirpsByIDs :many
+select * from chirps where id = ANY(sqlc.arg('ids')::uuid[]) and deleted_at is null;
+
+-- name: RepostCounts :many
+select repost_of_id::uuid as chirp_id,
+    count(*) filter (where body = '') as rechirps,
+    count(*) filter (where body <> '') as quotes
+from chirps
+where repost_of_id = ANY(sqlc.arg('chirp_ids')::uuid[]) and deleted_at is null
+group by repost_of_id;
@@ -0,0 +1,16 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps (id, created_at, updated_at, user_id, body, reply_to_id, publish_at)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: UserScheduledChirps :many
+select * from scheduled_chirps where user_id = $1
+order by publish_at, id
+limit $2 offset $3;
+
+-- name: CancelScheduledChirp :execrows
+delete from scheduled_chirps where id = $1 and user_id = $2;
+
+-- name: TakeScheduledChirp :one
+delete from scheduled_chirps where id = $1
+returning *;
@@ -1,2 +1,2 @@
 -- name: SingleChirp :one
-select * from chirps where id = $1;
\ No newline at end of file
+select * from chirps where id = $1 and deleted_at is null;
\ No newline at end of file
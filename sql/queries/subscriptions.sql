@@ -0,0 +1,30 @@
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (id, created_at, updated_at, plan, status, started_at, expires_at, user_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, 'active', NOW(), $2, $3)
+RETURNING *;
+
+-- name: LatestSubscription :one
+select * from subscriptions where user_id = $1 order by started_at desc limit 1;
+
+-- name: UpdateSubscription :one
+UPDATE subscriptions SET status = $2, expires_at = $3, canceled_at = $4, updated_at = NOW()
+where id = $1
+RETURNING *;
+
+-- name: UserIsChirpyRed :one
+select exists (
+    select 1 from subscriptions
+    where user_id = $1
+    and status in ('active', 'past_due', 'canceled')
+    and (expires_at is null or expires_at > NOW())
+)::boolean;
+
+-- name: CreateSubscriptionHistory :exec
+INSERT INTO subscription_history (id, created_at, event, status, expires_at, subscription_id)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4);
+
+-- name: UserSubscriptionHistory :many
+select subscription_history.* from subscription_history
+join subscriptions on subscriptions.id = subscription_history.subscription_id
+where subscriptions.user_id = $1
+order by subscription_history.created_at;
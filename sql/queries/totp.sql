@@ -0,0 +1,5 @@
+-- name: SetTOTPSecret :exec
+UPDATE users SET totp_secret = $2, totp_enabled = false, updated_at = NOW() where id = $1;
+
+-- name: EnableTOTP :exec
+UPDATE users SET totp_enabled = true, updated_at = NOW() where id = $1;
@@ -0,0 +1,3 @@
+-- name: UpdateChirp :one
+update chirps set body = $2, entities = $3, updated_at = NOW() where id = $1 and deleted_at is null
+returning *;
@@ -0,0 +1,2 @@
+-- name: UserByID :one
+SELECT * FROM users where id = $1;
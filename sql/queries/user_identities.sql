@@ -0,0 +1,6 @@
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (provider, subject, created_at, email, user_id)
+VALUES ($1, $2, NOW(), $3, $4);
+
+-- name: IdentityUserID :one
+select user_id from user_identities where provider = $1 and subject = $2;
@@ -0,0 +1,20 @@
+-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (id, created_at, updated_at, event, payload, status)
+VALUES ($1, NOW(), NOW(), $2, $3, 'pending')
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: WebhookEvent :one
+select * from webhook_events where id = $1;
+
+-- name: ListWebhookEvents :many
+select * from webhook_events
+where sqlc.narg('status')::text is null or status = sqlc.narg('status')::text
+order by created_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: FinishWebhookEvent :exec
+UPDATE webhook_events
+SET status = $2, error = $3, attempts = attempts + 1, updated_at = NOW(),
+    processed_at = CASE WHEN $2 = 'failed' THEN processed_at ELSE NOW() END
+where id = $1;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE users ADD totp_secret TEXT;
+ALTER TABLE users ADD totp_enabled boolean not null DEFAULT false;
+
+CREATE TABLE mfa_recovery_codes (
+    code_hash text PRIMARY KEY,
+    created_at timestamp not null,
+    used_at timestamp,
+    user_id uuid not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE mfa_recovery_codes;
+ALTER TABLE users DROP COLUMN totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE user_identities (
+    provider text not null,
+    subject text not null,
+    created_at timestamp not null,
+    email text,
+    user_id uuid not null,
+    PRIMARY KEY (provider, subject),
+    FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oidc_login_states (
+    state text PRIMARY KEY,
+    code_verifier text not null,
+    nonce text not null,
+    created_at timestamp not null,
+    expires_at timestamp not null
+);
+
+-- +goose Down
+DROP TABLE oidc_login_states;
+DROP TABLE user_identities;
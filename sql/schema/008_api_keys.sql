@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    name text not null,
+    key_prefix text not null,
+    key_hash text unique not null,
+    scopes text[] not null,
+    last_used_at timestamp,
+    revoked_at timestamp,
+    user_id uuid not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE refresh_tokens ADD scopes text[];
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP COLUMN scopes;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users ADD is_admin boolean not null DEFAULT false;
+
+CREATE TABLE webhook_events (
+    id text PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    event text not null,
+    payload jsonb not null,
+    status text not null,
+    error text,
+    attempts integer not null DEFAULT 0,
+    processed_at timestamp
+);
+
+CREATE INDEX webhook_events_status_idx ON webhook_events (status, created_at);
+
+-- +goose Down
+DROP TABLE webhook_events;
+ALTER TABLE users DROP COLUMN is_admin;
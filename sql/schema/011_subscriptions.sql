@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE subscriptions (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    plan text not null,
+    status text not null,
+    started_at timestamp not null,
+    expires_at timestamp,
+    canceled_at timestamp,
+    user_id uuid not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX subscriptions_user_idx ON subscriptions (user_id, started_at);
+
+CREATE TABLE subscription_history (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    event text not null,
+    status text not null,
+    expires_at timestamp,
+    subscription_id uuid not null,
+    FOREIGN KEY (subscription_id)
+    REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+INSERT INTO subscriptions (id, created_at, updated_at, plan, status, started_at, user_id)
+SELECT gen_random_uuid(), NOW(), NOW(), 'chirpy_red', 'active', updated_at, id
+FROM users WHERE is_chirpy_red;
+
+ALTER TABLE users DROP COLUMN is_chirpy_red;
+
+-- +goose Down
+ALTER TABLE users ADD is_chirpy_red boolean DEFAULT false;
+
+UPDATE users SET is_chirpy_red = true
+WHERE id IN (SELECT user_id FROM subscriptions WHERE status in ('active', 'past_due', 'canceled') and (expires_at is null or expires_at > NOW()));
+
+DROP TABLE subscription_history;
+DROP TABLE subscriptions;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE webhook_subscriptions (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    url text not null,
+    secret text not null,
+    events text[] not null,
+    active boolean not null DEFAULT true,
+    user_id uuid,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE webhook_deliveries (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    event text not null,
+    payload jsonb not null,
+    status text not null,
+    attempts integer not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    last_error text,
+    response_status integer,
+    delivered_at timestamp,
+    subscription_id uuid not null,
+    FOREIGN KEY (subscription_id)
+    REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    kind text not null,
+    payload jsonb not null,
+    status text not null,
+    attempts integer not null DEFAULT 0,
+    max_attempts integer not null,
+    run_at timestamp not null,
+    locked_at timestamp,
+    last_error text,
+    completed_at timestamp
+);
+
+CREATE INDEX jobs_due_idx ON jobs (status, run_at);
+
+-- +goose Down
+DROP TABLE jobs;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE chirp_stream_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamp not null,
+    event text not null,
+    chirp_id uuid not null,
+    user_id uuid not null,
+    payload jsonb not null
+);
+
+CREATE INDEX chirp_stream_events_created_idx ON chirp_stream_events (created_at);
+
+-- +goose Down
+DROP TABLE chirp_stream_events;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN reply_to_id uuid REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE INDEX chirps_reply_to_idx ON chirps (reply_to_id);
+
+ALTER TABLE chirp_stream_events ADD COLUMN recipient_id uuid;
+
+-- +goose Down
+ALTER TABLE chirp_stream_events DROP COLUMN recipient_id;
+ALTER TABLE chirps DROP COLUMN reply_to_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    actor_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    kind text not null,
+    chirp_id uuid REFERENCES chirps(id) ON DELETE CASCADE,
+    read_at timestamp
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE notifications;
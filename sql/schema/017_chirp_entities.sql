@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN entities jsonb not null default '[]';
+
+CREATE TABLE chirp_hashtags (
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    tag text not null,
+    created_at timestamp not null,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+CREATE INDEX chirp_hashtags_tag_idx ON chirp_hashtags (tag, created_at DESC);
+CREATE INDEX chirp_hashtags_created_idx ON chirp_hashtags (created_at);
+
+CREATE TABLE chirp_mentions (
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+DROP TABLE chirp_hashtags;
+ALTER TABLE chirps DROP COLUMN entities;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle text;
+ALTER TABLE users ADD COLUMN display_name text not null default '';
+ALTER TABLE users ADD COLUMN bio text not null default '';
+ALTER TABLE users ADD COLUMN avatar_url text not null default '';
+ALTER TABLE users ADD COLUMN handle_changed_at timestamp;
+
+CREATE UNIQUE INDEX users_handle_idx ON users (lower(handle));
+
+-- A handle given up is held for its previous owner for a while so it can't
+-- be grabbed to impersonate them.
+CREATE TABLE handle_reservations (
+    handle text PRIMARY KEY,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    reserved_until timestamp not null
+);
+
+-- +goose Down
+DROP TABLE handle_reservations;
+DROP INDEX users_handle_idx;
+ALTER TABLE users DROP COLUMN handle_changed_at;
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN display_name;
+ALTER TABLE users DROP COLUMN handle;
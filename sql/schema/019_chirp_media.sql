@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE chirp_media (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    position integer not null,
+    content_type text not null,
+    width integer not null,
+    height integer not null,
+    blob_key text not null,
+    thumb_key text not null
+);
+
+CREATE INDEX chirp_media_chirp_idx ON chirp_media (chirp_id, position);
+
+-- +goose Down
+DROP TABLE chirp_media;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE link_previews (
+    url text PRIMARY KEY,
+    fetched_at timestamp not null,
+    ok boolean not null,
+    title text not null default '',
+    description text not null default '',
+    image_url text not null default ''
+);
+
+CREATE TABLE chirp_links (
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    position integer not null,
+    url text not null,
+    PRIMARY KEY (chirp_id, position)
+);
+
+-- +goose Down
+DROP TABLE chirp_links;
+DROP TABLE link_previews;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN deletion_requested_at timestamp;
+
+CREATE TABLE data_exports (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    completed_at timestamp,
+    archive bytea
+);
+
+CREATE INDEX data_exports_user_idx ON data_exports (user_id, created_at);
+
+-- +goose Down
+DROP TABLE data_exports;
+ALTER TABLE users DROP COLUMN deletion_requested_at;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE audit_log (
+    id bigserial PRIMARY KEY,
+    created_at timestamp not null,
+    action text not null,
+    actor_id uuid,
+    target_id uuid,
+    ip text not null,
+    user_agent text not null,
+    request_id text not null,
+    details jsonb not null
+);
+
+CREATE INDEX audit_log_action_idx ON audit_log (action, id);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_target_idx ON audit_log (target_id, id);
+
+-- The log outlives the users it mentions, so actor_id and target_id have no
+-- foreign keys, and rows can never be changed or removed.
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE scheduled_chirps (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    body text not null,
+    reply_to_id uuid REFERENCES chirps(id) ON DELETE SET NULL,
+    publish_at timestamp not null
+);
+
+CREATE INDEX scheduled_chirps_user_idx ON scheduled_chirps (user_id, publish_at);
+
+-- +goose Down
+DROP TABLE scheduled_chirps;
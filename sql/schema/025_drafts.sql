@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    body text not null,
+    reply_to_id uuid REFERENCES chirps(id) ON DELETE SET NULL
+);
+
+CREATE INDEX drafts_user_idx ON drafts (user_id, updated_at);
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- No foreign key: a repost keeps pointing at its original after the original
+-- is purged, so it can still render a tombstone.
+ALTER TABLE chirps ADD COLUMN repost_of_id uuid;
+
+CREATE INDEX chirps_repost_of_idx ON chirps (repost_of_id) WHERE repost_of_id IS NOT NULL;
+
+-- A user can rechirp a chirp once; quotes (reposts with a body) are unlimited.
+CREATE UNIQUE INDEX chirps_rechirp_once_idx ON chirps (user_id, repost_of_id)
+WHERE repost_of_id IS NOT NULL AND body = '' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_rechirp_once_idx;
+DROP INDEX chirps_repost_of_idx;
+ALTER TABLE chirps DROP COLUMN repost_of_id;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE collections (
+    id uuid PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    name text not null,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE bookmarks (
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at timestamp not null,
+    collection_id uuid REFERENCES collections(id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_idx ON bookmarks (user_id, created_at DESC);
+CREATE INDEX bookmarks_chirp_idx ON bookmarks (chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE collections;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE mfa_attempts (
+    user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    failed_count int not null DEFAULT 0,
+    locked_until timestamp,
+    last_totp_step bigint not null DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE mfa_attempts;
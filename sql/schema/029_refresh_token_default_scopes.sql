@@ -0,0 +1,8 @@
+-- +goose Up
+-- Access tokens with no scope claim no longer fall back to full access, so
+-- refresh tokens issued before scopes existed get the defaults they stood for.
+UPDATE refresh_tokens
+SET scopes = '{chirps:read,chirps:write,users:write,keys:write,webhooks:write}'
+WHERE scopes IS NULL;
+
+-- +goose Down
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Notification jobs can run more than once; each (recipient, kind, chirp,
+-- actor) is notified at most once.
+DELETE FROM notifications a USING notifications b
+WHERE a.user_id = b.user_id
+and a.kind = b.kind
+and a.actor_id = b.actor_id
+and a.chirp_id IS NOT DISTINCT FROM b.chirp_id
+and (a.created_at, a.id) > (b.created_at, b.id);
+
+CREATE UNIQUE INDEX notifications_dedupe_idx ON notifications (
+    user_id, kind, actor_id, coalesce(chirp_id, '00000000-0000-0000-0000-000000000000'::uuid)
+);
+
+-- +goose Down
+DROP INDEX notifications_dedupe_idx;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    followee_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp not null,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE follows;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE chirp_reactions (
+    chirp_id uuid not null REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id uuid not null REFERENCES users(id) ON DELETE CASCADE,
+    reaction text not null,
+    created_at timestamp not null,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE chirp_reactions;
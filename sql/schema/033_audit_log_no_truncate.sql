@@ -0,0 +1,8 @@
+-- +goose Up
+-- Row triggers don't fire on TRUNCATE, so the append-only rule needs a
+-- statement trigger too.
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TRIGGER audit_log_no_truncate ON audit_log;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A scheduled reply keeps its target's ID after the target is purged, so
+-- publishing sees the target is gone and drops the reply instead of posting
+-- it as a top-level chirp.
+ALTER TABLE scheduled_chirps DROP CONSTRAINT scheduled_chirps_reply_to_id_fkey;
+
+-- +goose Down
+UPDATE scheduled_chirps SET reply_to_id = NULL
+WHERE NOT EXISTS (SELECT 1 FROM chirps WHERE chirps.id = scheduled_chirps.reply_to_id);
+ALTER TABLE scheduled_chirps ADD CONSTRAINT scheduled_chirps_reply_to_id_fkey
+FOREIGN KEY (reply_to_id) REFERENCES chirps(id) ON DELETE SET NULL;
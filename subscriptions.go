@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	subscriptionStatusActive   = "active"
+	subscriptionStatusPastDue  = "past_due"
+	subscriptionStatusCanceled = "canceled"
+	subscriptionStatusExpired  = "expired"
+
+	defaultPlan = "chirpy_red"
+)
+
+// Polka sending an event for a user or subscription we don't know about is
+// its mistake, not ours, and retrying won't fix it.
+var (
+	errUnknownSubscriber = errors.New("user not found")
+	errNoSubscription    = errors.New("no subscription")
+)
+
+type Subscription struct {
+	ID         uuid.UUID  `json:"id"`
+	Plan       string     `json:"plan"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CanceledAt *time.Time `json:"canceled_at"`
+}
+
+type SubscriptionHistory struct {
+	CreatedAt time.Time  `json:"created_at"`
+	Event     string     `json:"event"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// subscriptionEvent is the data Polka sends with subscription lifecycle
+// events. Plan and ExpiresAt are optional.
+type subscriptionEvent struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Plan      string     `json:"plan"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// isChirpyRed reports whether the user currently has a subscription that
+// entitles them to Chirpy Red.
+func (config *apiConfig) isChirpyRed(ctx context.Context, userID uuid.UUID) bool {
+	red, err := config.databaseQueries.UserIsChirpyRed(ctx, userID)
+	if err != nil {
+		fmt.Printf("Couldn't check subscription: %s\n", err)
+		return false
+	}
+	return red
+}
+
+// applySubscriptionEvent moves the user's latest subscription through its
+// lifecycle and records the change in its history. Run it in a transaction.
+func (config *apiConfig) applySubscriptionEvent(ctx context.Context, queries *database.Queries, event string, data subscriptionEvent) error {
+	latest, err := queries.LatestSubscription(ctx, data.UserID)
+	hasLatest := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if !hasLatest {
+		_, err = queries.UserByID(ctx, data.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUnknownSubscriber
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	var subscription database.Subscription
+
+	switch event {
+	case "user.upgraded":
+		// Only a subscription that's still running is upgraded in place. One
+		// that was canceled or has lapsed is history, and paying again
+		// starts a new one.
+		lapsed := latest.ExpiresAt.Valid && !latest.ExpiresAt.Time.After(now)
+		running := latest.Status == subscriptionStatusActive || latest.Status == subscriptionStatusPastDue
+		if hasLatest && running && !lapsed {
+			expiresAt := latest.ExpiresAt
+			if data.ExpiresAt != nil {
+				expiresAt = nullTime(data.ExpiresAt)
+			}
+			subscription, err = queries.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+				ID:        latest.ID,
+				Status:    subscriptionStatusActive,
+				ExpiresAt: expiresAt,
+			})
+			break
+		}
+		plan := data.Plan
+		if plan == "" {
+			plan = defaultPlan
+		}
+		subscription, err = queries.CreateSubscription(ctx, database.CreateSubscriptionParams{
+			Plan:      plan,
+			ExpiresAt: nullTime(data.ExpiresAt),
+			UserID:    data.UserID,
+		})
+	case "user.renewed":
+		if !hasLatest {
+			return fmt.Errorf("%w to renew", errNoSubscription)
+		}
+		expiresAt := latest.ExpiresAt
+		if data.ExpiresAt != nil {
+			expiresAt = nullTime(data.ExpiresAt)
+		}
+		subscription, err = queries.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+			ID:        latest.ID,
+			Status:    subscriptionStatusActive,
+			ExpiresAt: expiresAt,
+		})
+	case "user.payment_failed":
+		if !hasLatest {
+			return fmt.Errorf("%w for failed payment", errNoSubscription)
+		}
+		subscription, err = queries.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+			ID:         latest.ID,
+			Status:     subscriptionStatusPastDue,
+			ExpiresAt:  latest.ExpiresAt,
+			CanceledAt: latest.CanceledAt,
+		})
+	case "user.canceled":
+		// A cancellation keeps Red until the paid period ends; with no end
+		// date on record it takes effect immediately.
+		if !hasLatest {
+			return fmt.Errorf("%w to cancel", errNoSubscription)
+		}
+		expiresAt := latest.ExpiresAt
+		if !expiresAt.Valid {
+			expiresAt = sql.NullTime{Time: now, Valid: true}
+		}
+		subscription, err = queries.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+			ID:         latest.ID,
+			Status:     subscriptionStatusCanceled,
+			ExpiresAt:  expiresAt,
+			CanceledAt: sql.NullTime{Time: now, Valid: true},
+		})
+	case "user.downgraded":
+		if !hasLatest {
+			return fmt.Errorf("%w to downgrade", errNoSubscription)
+		}
+		subscription, err = queries.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+			ID:         latest.ID,
+			Status:     subscriptionStatusExpired,
+			ExpiresAt:  sql.NullTime{Time: now, Valid: true},
+			CanceledAt: latest.CanceledAt,
+		})
+	default:
+		return fmt.Errorf("unknown subscription event %q", event)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = queries.CreateSubscriptionHistory(ctx, database.CreateSubscriptionHistoryParams{
+		Event:          event,
+		Status:         subscription.Status,
+		ExpiresAt:      subscription.ExpiresAt,
+		SubscriptionID: subscription.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if event == "user.upgraded" {
+		return config.emitEvent(ctx, queries, webhooks.EventUserUpgraded, data.UserID, map[string]interface{}{
+			"user_id": data.UserID,
+			"plan":    subscription.Plan,
+		})
+	}
+	return nil
+}
+
+func (config *apiConfig) subscriptionHandler(writer http.ResponseWriter, request *http.Request) {
+	type response struct {
+		IsChirpyRed  bool                  `json:"is_chirpy_red"`
+		Subscription *Subscription         `json:"subscription"`
+		History      []SubscriptionHistory `json:"history"`
+	}
+
+	userId := principalFromContext(request.Context()).UserID
+
+	returnValue := response{
+		IsChirpyRed: config.isChirpyRed(request.Context(), userId),
+		History:     []SubscriptionHistory{},
+	}
+
+	latest, err := config.databaseQueries.LatestSubscription(request.Context(), userId)
+	if err == nil {
+		returnValue.Subscription = &Subscription{
+			ID:         latest.ID,
+			Plan:       latest.Plan,
+			Status:     latest.Status,
+			StartedAt:  latest.StartedAt,
+			ExpiresAt:  timePointer(latest.ExpiresAt),
+			CanceledAt: timePointer(latest.CanceledAt),
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load subscription")
+		return
+	}
+
+	dbHistory, err := config.databaseQueries.UserSubscriptionHistory(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load subscription history")
+		return
+	}
+	for _, entry := range dbHistory {
+		returnValue.History = append(returnValue.History, SubscriptionHistory{
+			CreatedAt: entry.CreatedAt,
+			Event:     entry.Event,
+			Status:    entry.Status,
+			ExpiresAt: timePointer(entry.ExpiresAt),
+		})
+	}
+
+	respondWithJSON(writer, http.StatusOK, returnValue)
+}
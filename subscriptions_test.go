@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+var subscriptionColumns = []string{"id", "created_at", "updated_at", "plan", "status", "started_at", "expires_at", "canceled_at", "user_id"}
+
+// fakeSubscriptions is the one user the database knows, with their
+// subscription rows, answering the subscription queries over them.
+type fakeSubscriptions struct {
+	userID uuid.UUID
+	rows   [][]driver.Value
+}
+
+func (fake *fakeSubscriptions) install(db *dbtest.DB) {
+	db.Handle("UserByID", func(args []driver.Value) (dbtest.Rows, error) {
+		if args[0] != fake.userID.String() {
+			return dbtest.Rows{Columns: userColumns}, nil
+		}
+		return dbtest.Rows{Columns: userColumns, Values: [][]driver.Value{userRow(fake.userID, nil)}}, nil
+	})
+	db.Handle("LatestSubscription", func(args []driver.Value) (dbtest.Rows, error) {
+		if len(fake.rows) == 0 {
+			return dbtest.Rows{Columns: subscriptionColumns}, nil
+		}
+		return dbtest.Rows{Columns: subscriptionColumns, Values: [][]driver.Value{fake.latest()}}, nil
+	})
+	db.Handle("CreateSubscription", func(args []driver.Value) (dbtest.Rows, error) {
+		// plan, expires_at, user_id
+		now := time.Now().UTC()
+		row := []driver.Value{uuid.NewString(), now, now, args[0], subscriptionStatusActive, now, args[1], nil, args[2]}
+		fake.rows = append(fake.rows, row)
+		return dbtest.Rows{Columns: subscriptionColumns, Values: [][]driver.Value{row}}, nil
+	})
+	db.Handle("UpdateSubscription", func(args []driver.Value) (dbtest.Rows, error) {
+		// id, status, expires_at, canceled_at
+		row := fake.latest()
+		row[4], row[6], row[7] = args[1], args[2], args[3]
+		return dbtest.Rows{Columns: subscriptionColumns, Values: [][]driver.Value{row}}, nil
+	})
+	db.Handle("UserIsChirpyRed", func(args []driver.Value) (dbtest.Rows, error) {
+		red := false
+		for _, row := range fake.rows {
+			expiresAt, expires := row[6].(time.Time)
+			if row[4] != subscriptionStatusExpired && (!expires || expiresAt.After(time.Now().UTC())) {
+				red = true
+			}
+		}
+		return dbtest.Rows{Columns: []string{"exists"}, Values: [][]driver.Value{{red}}}, nil
+	})
+	db.Handle("CreateSubscriptionHistory", func([]driver.Value) (dbtest.Rows, error) { return dbtest.Rows{Affected: 1}, nil })
+	db.Handle("CreateJob", func(args []driver.Value) (dbtest.Rows, error) {
+		now := time.Now().UTC()
+		return dbtest.Rows{
+			Columns: []string{"id", "created_at", "updated_at", "kind", "payload", "status", "attempts",
+				"max_attempts", "run_at", "locked_at", "last_error", "completed_at"},
+			Values: [][]driver.Value{{uuid.NewString(), now, now, args[0], args[1], "pending", int64(0), args[2], args[3], nil, nil, nil}},
+		}, nil
+	})
+}
+
+func (fake *fakeSubscriptions) latest() []driver.Value {
+	return fake.rows[len(fake.rows)-1]
+}
+
+func TestResubscribeAfterCanceledSubscriptionLapses(t *testing.T) {
+	fake := &fakeSubscriptions{userID: uuid.New()}
+	db := dbtest.New()
+	fake.install(db)
+	config := apiConfig{db: db.DB, databaseQueries: database.New(db)}
+	queries := database.New(db)
+	ctx := context.Background()
+	paidUntil := time.Now().UTC().Add(24 * time.Hour)
+
+	steps := []struct {
+		event     string
+		expiresAt *time.Time
+		lapse     bool
+		wantRed   bool
+		wantRows  int
+	}{
+		{event: "user.upgraded", expiresAt: &paidUntil, wantRed: true, wantRows: 1},
+		{event: "user.canceled", wantRed: true, wantRows: 1},
+		{event: "", lapse: true, wantRed: false, wantRows: 1},
+		{event: "user.upgraded", wantRed: true, wantRows: 2},
+	}
+
+	for i, step := range steps {
+		if step.lapse {
+			fake.latest()[6] = time.Now().UTC().Add(-time.Hour)
+		} else {
+			err := config.applySubscriptionEvent(ctx, queries, step.event, subscriptionEvent{
+				UserID:    fake.userID,
+				ExpiresAt: step.expiresAt,
+			})
+			if err != nil {
+				t.Fatalf("step %d: applySubscriptionEvent(%s) error = %v", i, step.event, err)
+			}
+		}
+		if got := config.isChirpyRed(ctx, fake.userID); got != step.wantRed {
+			t.Errorf("step %d: isChirpyRed() = %v, want %v", i, got, step.wantRed)
+		}
+		if len(fake.rows) != step.wantRows {
+			t.Errorf("step %d: %d subscriptions, want %d", i, len(fake.rows), step.wantRows)
+		}
+	}
+	if fake.latest()[4] != subscriptionStatusActive || fake.latest()[6] != nil {
+		t.Errorf("latest subscription = %v, want active with no expiry", fake.latest())
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+)
+
+// withTx runs fn with queries bound to a transaction, committing if fn returns
+// nil and rolling back otherwise.
+func (config *apiConfig) withTx(ctx context.Context, fn func(queries *database.Queries) error) error {
+	tx, err := config.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(config.databaseQueries.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -18,11 +19,16 @@ type User struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
 }
 
 type EmailPassword struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	Scopes   []string `json:"scopes"`
 }
 
 type Response struct {
@@ -62,27 +68,12 @@ func (config *apiConfig) usersHandler(writer http.ResponseWriter, request *http.
 		return
 	}
 
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed.Bool,
-	}
+	user := config.userFromDB(request.Context(), dbUser)
 	respondWithJSON(writer, 201, user)
 }
 
 func (config *apiConfig) usersUpdateHandler(writer http.ResponseWriter, request *http.Request) {
-	accessToken, err := auth.GetBearerToken(request.Header)
-	if err != nil {
-		respondWithError(writer, 401, "No Access")
-		return
-	}
-	userId, err := auth.ValidateJWT(accessToken, config.secret)
-	if err != nil {
-		respondWithError(writer, 401, "No Access")
-		return
-	}
+	userId := principalFromContext(request.Context()).UserID
 
 	type parameters struct {
 		Password string `json:"password"`
@@ -91,14 +82,22 @@ func (config *apiConfig) usersUpdateHandler(writer http.ResponseWriter, request
 	params := parameters{}
 
 	decoder := json.NewDecoder(request.Body)
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(writer, 500, "Invalid JSON")
+		return
 	}
 
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(writer, 500, "Password Failure")
+		return
+	}
+
+	previous, err := config.databaseQueries.UserByID(request.Context(), userId)
+	if err != nil {
+		respondWithError(writer, 500, "Error updating user")
+		return
 	}
 
 	dbParams := database.UpdatePassEmailParams{
@@ -113,16 +112,32 @@ func (config *apiConfig) usersUpdateHandler(writer http.ResponseWriter, request
 	dbUser, err := config.databaseQueries.UpdatePassEmail(request.Context(), dbParams)
 	if err != nil {
 		respondWithError(writer, 500, "Error updating user")
+		return
 	}
+	config.audit(request.Context(), auditCredentialsChanged, userId, userId, map[string]interface{}{
+		"password_changed": true,
+		"email_changed":    previous.Email != dbUser.Email,
+		"previous_email":   previous.Email,
+	})
 
-	user := User{
+	user := config.userFromDB(request.Context(), dbUser)
+	respondWithJSON(writer, 200, user)
+}
+
+// userFromDB builds the User payload, deriving IsChirpyRed from the user's
+// subscription.
+func (config *apiConfig) userFromDB(ctx context.Context, dbUser database.User) User {
+	return User{
 		ID:          dbUser.ID,
 		CreatedAt:   dbUser.CreatedAt,
 		UpdatedAt:   dbUser.UpdatedAt,
 		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed.Bool,
+		IsChirpyRed: config.isChirpyRed(ctx, dbUser.ID),
+		Handle:      dbUser.Handle.String,
+		DisplayName: dbUser.DisplayName,
+		Bio:         dbUser.Bio,
+		AvatarURL:   dbUser.AvatarURL,
 	}
-	respondWithJSON(writer, 200, user)
 }
 
 func decodeEmailPassword(request *http.Request) (EmailPassword, error) {
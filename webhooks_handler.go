@@ -1,46 +1,221 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
 	"github.com/google/uuid"
 )
 
+const (
+	webhookSignatureHeader = "X-Polka-Signature"
+	webhookTolerance       = 5 * time.Minute
+	webhookMaxBody         = 1 << 20
+)
+
+const (
+	webhookStatusProcessed = "processed"
+	webhookStatusIgnored   = "ignored"
+	webhookStatusFailed    = "failed"
+)
+
+type WebhookEvent struct {
+	ID          string          `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+func webhookEventFromDB(dbEvent database.WebhookEvent) WebhookEvent {
+	event := WebhookEvent{
+		ID:        dbEvent.ID,
+		CreatedAt: dbEvent.CreatedAt,
+		UpdatedAt: dbEvent.UpdatedAt,
+		Event:     dbEvent.Event,
+		Payload:   dbEvent.Payload,
+		Status:    dbEvent.Status,
+		Error:     dbEvent.Error.String,
+		Attempts:  dbEvent.Attempts,
+	}
+	if dbEvent.ProcessedAt.Valid {
+		event.ProcessedAt = &dbEvent.ProcessedAt.Time
+	}
+	return event
+}
+
+type polkaEvent struct {
+	ID    string            `json:"id"`
+	Event string            `json:"event"`
+	Data  subscriptionEvent `json:"data"`
+}
+
+// webhooksHandler records every Polka delivery before acting on it, so
+// retried deliveries of an event that already succeeded are acknowledged
+// without being applied twice.
 func (config *apiConfig) webhooksHandler(writer http.ResponseWriter, request *http.Request) {
-	apiKeyFromHeader, err := auth.GetAPIKey(request.Header)
+	body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, webhookMaxBody))
 	if err != nil {
-		respondWithError(writer, 401, "Auth Not Found")
+		respondWithError(writer, http.StatusBadRequest, "Couldn't read body")
+		return
+	}
+
+	err = auth.VerifySignature(request.Header.Get(webhookSignatureHeader), body, config.polkaKeys, webhookTolerance, time.Now())
+	if errors.Is(err, auth.ErrMalformedSignature) {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
 		return
 	}
-	if apiKeyFromHeader != config.polkaKey {
-		respondWithError(writer, 401, "Auth Not Found")
+	if err != nil {
+		respondWithError(writer, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	type inputParams struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
-		} `json:"data"`
+	params := polkaEvent{}
+	err = json.Unmarshal(body, &params)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if params.ID == "" {
+		respondWithError(writer, http.StatusBadRequest, "Missing event ID")
+		return
+	}
+
+	dbEvent, err := config.databaseQueries.CreateWebhookEvent(request.Context(), database.CreateWebhookEventParams{
+		ID:      params.ID,
+		Event:   params.Event,
+		Payload: body,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		dbEvent, err = config.databaseQueries.WebhookEvent(request.Context(), params.ID)
 	}
-	params := inputParams{}
-	decoder := json.NewDecoder(request.Body)
-	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithError(writer, 500, fmt.Sprintf("Invalid JSON: %s", err))
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't record event")
 		return
 	}
-	if params.Event != "user.upgraded" {
+
+	if dbEvent.Status == webhookStatusProcessed || dbEvent.Status == webhookStatusIgnored {
 		writer.WriteHeader(http.StatusNoContent)
 		return
 	}
-	err = config.databaseQueries.UpdateUsersRed(request.Context(), params.Data.UserID)
-	if err != nil {
+
+	err = config.processWebhookEvent(request.Context(), dbEvent)
+	if errors.Is(err, errUnknownSubscriber) {
 		respondWithError(writer, http.StatusNotFound, "User not found")
 		return
 	}
+	if errors.Is(err, errNoSubscription) {
+		respondWithError(writer, http.StatusNotFound, "Subscription not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't process event")
+		return
+	}
 	writer.WriteHeader(http.StatusNoContent)
 }
+
+// processWebhookEvent applies a stored event and records the outcome on it.
+func (config *apiConfig) processWebhookEvent(ctx context.Context, dbEvent database.WebhookEvent) error {
+	status, err := config.applyWebhookEvent(ctx, dbEvent)
+
+	finish := database.FinishWebhookEventParams{
+		ID:     dbEvent.ID,
+		Status: status,
+	}
+	if err != nil {
+		finish.Status = webhookStatusFailed
+		finish.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	finishErr := config.databaseQueries.FinishWebhookEvent(ctx, finish)
+	if err != nil {
+		return err
+	}
+	return finishErr
+}
+
+func (config *apiConfig) applyWebhookEvent(ctx context.Context, dbEvent database.WebhookEvent) (string, error) {
+	params := polkaEvent{}
+	err := json.Unmarshal(dbEvent.Payload, &params)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+
+	switch params.Event {
+	case "user.upgraded", "user.renewed", "user.payment_failed", "user.canceled", "user.downgraded":
+		err = config.withTx(ctx, func(queries *database.Queries) error {
+			err := config.applySubscriptionEvent(ctx, queries, params.Event, params.Data)
+			if err != nil || params.Event != "user.upgraded" {
+				return err
+			}
+			return recordAudit(ctx, queries, auditSubscriptionUpgraded, uuid.Nil, params.Data.UserID, map[string]string{
+				"webhook_event_id": dbEvent.ID,
+				"plan":             params.Data.Plan,
+			})
+		})
+		if err != nil {
+			return "", err
+		}
+		return webhookStatusProcessed, nil
+	default:
+		return webhookStatusIgnored, nil
+	}
+}
+
+func (config *apiConfig) listWebhookEventsHandler(writer http.ResponseWriter, request *http.Request) {
+	limit, offset, err := pageParams(request)
+	if err != nil {
+		respondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := request.URL.Query().Get("status")
+	dbEvents, err := config.databaseQueries.ListWebhookEvents(request.Context(), database.ListWebhookEventsParams{
+		Limit:  limit,
+		Offset: offset,
+		Status: sql.NullString{String: status, Valid: status != ""},
+	})
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't list events")
+		return
+	}
+
+	events := []WebhookEvent{}
+	for _, dbEvent := range dbEvents {
+		events = append(events, webhookEventFromDB(dbEvent))
+	}
+	respondWithJSON(writer, http.StatusOK, events)
+}
+
+// replayWebhookEventHandler reprocesses a stored event whatever its status,
+// for recovering from failures once the underlying problem is fixed.
+func (config *apiConfig) replayWebhookEventHandler(writer http.ResponseWriter, request *http.Request) {
+	dbEvent, err := config.databaseQueries.WebhookEvent(request.Context(), request.PathValue("eventID"))
+	if err != nil {
+		respondWithError(writer, http.StatusNotFound, "No event found")
+		return
+	}
+
+	// The outcome is recorded on the event, so a failure is reported through
+	// the returned status rather than an error response.
+	config.processWebhookEvent(request.Context(), dbEvent)
+
+	dbEvent, err = config.databaseQueries.WebhookEvent(request.Context(), dbEvent.ID)
+	if err != nil {
+		respondWithError(writer, http.StatusInternalServerError, "Couldn't load event")
+		return
+	}
+	respondWithJSON(writer, http.StatusOK, webhookEventFromDB(dbEvent))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amstein4920/chirpy-http-server/internal/auth"
+	"github.com/amstein4920/chirpy-http-server/internal/database"
+	"github.com/amstein4920/chirpy-http-server/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+func TestWebhookForUnknownSubscriber(t *testing.T) {
+	known := uuid.New()
+	tests := []struct {
+		name   string
+		event  string
+		userID uuid.UUID
+	}{
+		{name: "Renewal without a subscription", event: "user.renewed", userID: known},
+		{name: "Failed payment without a subscription", event: "user.payment_failed", userID: known},
+		{name: "Upgrade of an unknown user", event: "user.upgraded", userID: uuid.New()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.New()
+			(&fakeSubscriptions{userID: known}).install(db)
+			db.Handle("CreateWebhookEvent", func(args []driver.Value) (dbtest.Rows, error) {
+				now := time.Now().UTC()
+				return dbtest.Rows{
+					Columns: []string{"id", "created_at", "updated_at", "event", "payload", "status", "error", "attempts", "processed_at"},
+					Values:  [][]driver.Value{{args[0], now, now, args[1], args[2], "pending", nil, int64(0), nil}},
+				}, nil
+			})
+			db.Handle("FinishWebhookEvent", func([]driver.Value) (dbtest.Rows, error) { return dbtest.Rows{Affected: 1}, nil })
+			config := apiConfig{db: db.DB, databaseQueries: database.New(db), polkaKeys: []string{"polka"}}
+
+			body, _ := json.Marshal(polkaEvent{ID: uuid.NewString(), Event: tt.event, Data: subscriptionEvent{UserID: tt.userID}})
+			request := httptest.NewRequest("POST", "/api/polka/webhooks", bytes.NewReader(body))
+			request.Header.Set(webhookSignatureHeader, auth.SignPayload("polka", time.Now(), body))
+			recorder := httptest.NewRecorder()
+			config.webhooksHandler(recorder, request)
+
+			if recorder.Code != http.StatusNotFound {
+				t.Errorf("webhooksHandler() status = %d, want %d", recorder.Code, http.StatusNotFound)
+			}
+			calls := db.Calls("FinishWebhookEvent")
+			if len(calls) != 1 || calls[0].Args[1] != webhookStatusFailed {
+				t.Errorf("FinishWebhookEvent calls = %v, want one marking the event failed", calls)
+			}
+		})
+	}
+}